@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+// prefixWriter prepends a prefix to every line written through it
+type prefixWriter struct {
+	w       io.Writer
+	prefix  string
+	midLine bool
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	written := 0
+	for len(b) > 0 {
+		if !p.midLine {
+			if _, err := io.WriteString(p.w, p.prefix); err != nil {
+				return written, err
+			}
+			p.midLine = true
+		}
+
+		line := b
+		if idx := bytes.IndexByte(b, '\n'); idx >= 0 {
+			line = b[:idx+1]
+			p.midLine = false
+		}
+
+		n, err := p.w.Write(line)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		b = b[len(line):]
+	}
+	return written, nil
+}
+
+// Display all branches of the conversation as an indented tree.
+// Only entries in visible are shown, unless it is nil.
+func displayBranches(entries []*transcript.Entry, tree *transcript.Tree, tools transcript.ToolIndex, visible map[*transcript.Entry]bool) {
+	// Entries without a uuid are not part of the tree; show them first
+	for _, entry := range entries {
+		if entry.UUID == "" && (visible == nil || visible[entry]) {
+			displayEntryWithToolInfo(entry, tools)
+		}
+	}
+
+	for _, root := range tree.Roots {
+		displayBranch(root, 0, tools, visible)
+	}
+}
+
+// Display the entries of a branch up to its forks, in file order, then
+// descend into each fork with one more level of indentation
+func displayBranch(node *transcript.Node, depth int, tools transcript.ToolIndex, visible map[*transcript.Entry]bool) {
+	stdout := output
+	defer func() { output = stdout }()
+
+	gutter := strings.Repeat(color(colorGray)+"│ "+colorReset, depth)
+	output = &prefixWriter{w: stdout, prefix: gutter}
+
+	// Parallel tool results and split messages continue the branch side by side
+	var segment, forked []*transcript.Node
+	stack := []*transcript.Node{node}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		segment = append(segment, node)
+
+		forks := node.Forks()
+		if len(forks) > 0 {
+			forked = append(forked, node)
+		}
+		for _, child := range node.Children {
+			if !slices.Contains(forks, child) {
+				stack = append(stack, child)
+			}
+		}
+	}
+	byIndex := func(a, b *transcript.Node) int { return a.Index() - b.Index() }
+	slices.SortFunc(segment, byIndex)
+	slices.SortFunc(forked, byIndex)
+
+	for _, node := range segment {
+		if visible == nil || visible[node.Entry] {
+			displayEntryWithToolInfo(node.Entry, tools)
+		}
+	}
+	for _, node := range forked {
+		forks := node.Forks()
+		for i, child := range forks {
+			displayBranchHeader(i+1, len(forks), child)
+			displayBranch(child, depth+1, tools, visible)
+		}
+	}
+}
+
+// Display the header line introducing a branch
+func displayBranchHeader(n, total int, child *transcript.Node) {
+	state, stateColor := "abandoned", colorGray
+	if child.Active {
+		state, stateColor = "active", colorGreen
+	}
+	connector := "├─"
+	if n == total {
+		connector = "└─"
+	}
+	size := child.Size()
+	fmt.Fprintf(output, "%s%s branch %d/%d%s %s(%s, %d entr%s)%s\n",
+		color(colorYellow), connector, n, total, colorReset,
+		color(stateColor), state, size, pluralizeEntry(size), colorReset)
+}
+
+// pluralizeEntry returns the suffix for "entry"/"entries"
+func pluralizeEntry(count int) string {
+	if count == 1 {
+		return "y"
+	}
+	return "ies"
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := &prefixWriter{w: &buf, prefix: "> "}
+	_, _ = w.Write([]byte("one\ntw"))
+	_, _ = w.Write([]byte("o\n\nthree"))
+	expected := "> one\n> two\n> \n> three"
+	if buf.String() != expected {
+		t.Errorf("prefixWriter output = %q; want %q", buf.String(), expected)
+	}
+}
+
+func TestDisplayBranches(t *testing.T) {
+	origCfg := cfg
+	origOutput := output
+	defer func() {
+		cfg = origCfg
+		output = origOutput
+	}()
+	cfg.NoColor = true
+	cfg.Compact = true
+
+	input := strings.Join([]string{
+		`{"type":"user","uuid":"u1","message":{"content":"Start"}}`,
+		`{"type":"user","uuid":"u2","parentUuid":"u1","message":{"content":"First attempt"}}`,
+		`{"type":"user","uuid":"u3","parentUuid":"u1","message":{"content":"Edited prompt"}}`,
+	}, "\n")
+	entries, err := transcript.ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	output = &buf
+	displayBranches(entries, transcript.BuildTree(entries), transcript.NewToolIndex(entries), nil)
+
+	result := buf.String()
+	for _, want := range []string{
+		"branch 1/2",
+		"(abandoned, 1 entry)",
+		"│ ",
+		"└─ branch 2/2",
+		"(active, 1 entry)",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("branch output missing %q:\n%s", want, result)
+		}
+	}
+	if strings.Index(result, "First attempt") > strings.Index(result, "Edited prompt") {
+		t.Errorf("branches should be rendered in file order:\n%s", result)
+	}
+}
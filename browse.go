@@ -0,0 +1,767 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Sixeight/ccl/projects"
+	"github.com/Sixeight/ccl/transcript"
+	"golang.org/x/term"
+)
+
+// Terminal control sequences used by the browser
+const (
+	escAltScreen  = "\033[?1049h"
+	escMainScreen = "\033[?1049l"
+	escHideCursor = "\033[?25l"
+	escShowCursor = "\033[?25h"
+	escHome       = "\033[H"
+	escClearLine  = "\033[K"
+	escReverse    = "\033[7m"
+)
+
+// Roles that can be toggled in the message view, in key order (1, 2, 3)
+var browseRoles = []string{"user", "assistant", "tool"}
+
+// browser is the state of the full-screen session browser
+type browser struct {
+	sessions   []projects.Session
+	view       *sessionView // Open session, nil on the session list
+	input      *string      // Tool filter being edited, nil when not editing
+	err        error        // Shown in place of the key help until the next key
+	listCursor int
+	listOffset int
+	width      int
+	height     int
+	quit       bool
+}
+
+// viewLine is one screen line of the message view
+type viewLine struct {
+	text  string
+	entry int // Index into sessionView.entries
+}
+
+// sessionView is an open session rendered as scrollable lines
+type sessionView struct {
+	session    projects.Session
+	entries    []*transcript.Entry
+	tools      transcript.ToolIndex
+	expanded   map[int]bool    // Entries whose tool details are shown in full
+	hiddenRole map[string]bool // Roles toggled off
+	toolFilter string
+	lines      []viewLine
+	visible    []int       // Indices of rendered entries, in order
+	position   map[int]int // Entry index -> position in visible
+	starts     []int       // First line of each visible entry
+	cursor     int         // Position in visible
+	offset     int         // First line on screen
+	width      int
+	height     int
+}
+
+// runBrowseCommand runs the browse subcommand
+func runBrowseCommand(args []string) {
+	browseCmd := flag.NewFlagSet("browse", flag.ExitOnError)
+	browseCmd.BoolVar(&cfg.NoColor, "no-color", false, "disable color output")
+	current := browseCmd.Bool("current", false, "list only the current directory's sessions")
+
+	browseCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ccl browse [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Browse sessions and messages in a full-screen terminal UI.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		browseCmd.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nKeys:\n")
+		fmt.Fprintf(os.Stderr, "  j/k, arrows     move between sessions or messages\n")
+		fmt.Fprintf(os.Stderr, "  space/b         page down/up\n")
+		fmt.Fprintf(os.Stderr, "  g/G             first/last\n")
+		fmt.Fprintf(os.Stderr, "  enter           open session, expand or collapse tool details\n")
+		fmt.Fprintf(os.Stderr, "  e/c             expand/collapse all tool details\n")
+		fmt.Fprintf(os.Stderr, "  n/p             next/previous user prompt\n")
+		fmt.Fprintf(os.Stderr, "  1/2/3           toggle user/assistant/tool messages\n")
+		fmt.Fprintf(os.Stderr, "  t/T             set/clear tool filter (supports glob)\n")
+		fmt.Fprintf(os.Stderr, "  q               back to session list, or quit\n")
+	}
+
+	if err := browseCmd.Parse(args); err != nil {
+		return
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Fprintf(os.Stderr, "Error: browse requires an interactive terminal\n")
+		os.Exit(1)
+	}
+
+	var sessions []projects.Session
+	if *current {
+		cwd, _ := os.Getwd()
+		var err error
+		if sessions, err = projects.ForProject(cwd); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
+		}
+	} else {
+		sessions = collectAllProjectFiles()
+	}
+	if len(sessions) == 0 {
+		fmt.Println("No project files found")
+		return
+	}
+	projects.SortByModTime(sessions)
+	projects.ShortenNames(sessions)
+
+	// Rendering settings that make no sense interactively
+	cfg.ShowTiming = false
+	cfg.OutputFormat = "text"
+
+	b := &browser{sessions: sessions}
+	if err := b.run(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+}
+
+// run drives the browser until the user quits
+func (b *browser) run(in *os.File, out *os.File) error {
+	state, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return fmt.Errorf("setting terminal mode: %w", err)
+	}
+	defer func() { _ = term.Restore(int(in.Fd()), state) }()
+
+	fmt.Fprint(out, escAltScreen+escHideCursor)
+	defer fmt.Fprint(out, escShowCursor+escMainScreen)
+
+	keys := make(chan []byte)
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			n, err := in.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			keys <- append([]byte(nil), buf[:n]...)
+		}
+	}()
+
+	resize := make(chan os.Signal, 1)
+	notifyResize(resize)
+	defer signal.Stop(resize)
+
+	for !b.quit {
+		if width, height, err := term.GetSize(int(out.Fd())); err == nil {
+			b.resize(width, height)
+		}
+		b.draw(out)
+
+		select {
+		case chunk, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			for _, key := range parseKeys(chunk) {
+				b.handleKey(key)
+			}
+		case <-resize:
+		}
+	}
+	return nil
+}
+
+// resize records the terminal size, relaying out the open session if needed
+func (b *browser) resize(width, height int) {
+	b.width, b.height = width, height
+	if b.view != nil && (b.view.width != width || b.view.height != height) {
+		b.view.width, b.view.height = width, height
+		b.view.layout()
+	}
+}
+
+// Names of the special keys recognized in escape sequences
+var escapeKeys = map[string]string{
+	"A": "up", "B": "down", "C": "right", "D": "left",
+	"H": "home", "F": "end", "1~": "home", "4~": "end",
+	"5~": "pgup", "6~": "pgdn",
+}
+
+// parseKeys splits raw terminal input into key names.
+// Printable characters are returned as themselves.
+func parseKeys(b []byte) []string {
+	var keys []string
+	for len(b) > 0 {
+		if b[0] == 0x1b {
+			if len(b) > 2 && (b[1] == '[' || b[1] == 'O') {
+				end := 2
+				for end < len(b) && (b[end] < 0x40 || b[end] > 0x7e) {
+					end++
+				}
+				if end < len(b) {
+					if name, ok := escapeKeys[string(b[2:end+1])]; ok {
+						keys = append(keys, name)
+					}
+					b = b[end+1:]
+					continue
+				}
+			}
+			keys = append(keys, "esc")
+			b = b[1:]
+			continue
+		}
+
+		r, size := utf8.DecodeRune(b)
+		b = b[size:]
+		switch r {
+		case '\r', '\n':
+			keys = append(keys, "enter")
+		case '\t':
+			keys = append(keys, "tab")
+		case 0x7f, 0x08:
+			keys = append(keys, "backspace")
+		case 0x03:
+			keys = append(keys, "ctrl-c")
+		case 0x04:
+			keys = append(keys, "ctrl-d")
+		case 0x15:
+			keys = append(keys, "ctrl-u")
+		default:
+			if r >= 0x20 {
+				keys = append(keys, string(r))
+			}
+		}
+	}
+	return keys
+}
+
+// handleKey applies a key press to the current screen
+func (b *browser) handleKey(key string) {
+	if key == "ctrl-c" {
+		b.quit = true
+		return
+	}
+	b.err = nil
+	switch {
+	case b.input != nil:
+		b.handleInputKey(key)
+	case b.view != nil:
+		b.handleViewKey(key)
+	default:
+		b.handleListKey(key)
+	}
+}
+
+// handleInputKey edits the tool filter prompt
+func (b *browser) handleInputKey(key string) {
+	switch key {
+	case "enter":
+		b.view.toolFilter = *b.input
+		b.input = nil
+		b.view.layout()
+	case "esc":
+		b.input = nil
+	case "backspace":
+		if runes := []rune(*b.input); len(runes) > 0 {
+			*b.input = string(runes[:len(runes)-1])
+		}
+	default:
+		if utf8.RuneCountInString(key) == 1 {
+			*b.input += key
+		}
+	}
+}
+
+// handleListKey navigates the session list
+func (b *browser) handleListKey(key string) {
+	page := b.listHeight()
+	switch key {
+	case "j", "down":
+		b.listCursor++
+	case "k", "up":
+		b.listCursor--
+	case " ", "pgdn", "ctrl-d":
+		b.listCursor += page
+	case "b", "pgup", "ctrl-u":
+		b.listCursor -= page
+	case "g", "home":
+		b.listCursor = 0
+	case "G", "end":
+		b.listCursor = len(b.sessions) - 1
+	case "enter", "l", "right":
+		b.err = b.open(b.sessions[b.listCursor])
+		return
+	case "q", "esc":
+		b.quit = true
+		return
+	}
+	b.listCursor = clamp(b.listCursor, 0, len(b.sessions)-1)
+	if b.listCursor < b.listOffset {
+		b.listOffset = b.listCursor
+	}
+	if b.listCursor >= b.listOffset+page {
+		b.listOffset = b.listCursor - page + 1
+	}
+}
+
+// open loads a session into the message view
+func (b *browser) open(session projects.Session) error {
+	entries, err := transcript.ReadFile(session.Path)
+	if err != nil {
+		return err
+	}
+	b.view = newSessionView(session, entries, b.width, b.height)
+	return nil
+}
+
+// handleViewKey navigates the message view
+func (b *browser) handleViewKey(key string) {
+	v := b.view
+	switch key {
+	case "j", "down":
+		v.moveCursor(v.cursor + 1)
+	case "k", "up":
+		v.moveCursor(v.cursor - 1)
+	case " ", "pgdn", "ctrl-d":
+		v.scroll(v.bodyHeight())
+	case "b", "pgup", "ctrl-u":
+		v.scroll(-v.bodyHeight())
+	case "g", "home":
+		v.moveCursor(0)
+	case "G", "end":
+		v.moveCursor(len(v.visible) - 1)
+	case "n":
+		v.jumpPrompt(1)
+	case "p", "N":
+		v.jumpPrompt(-1)
+	case "enter", "tab", "o":
+		if len(v.visible) > 0 {
+			entry := v.visible[v.cursor]
+			v.expanded[entry] = !v.expanded[entry]
+			v.layout()
+		}
+	case "e", "c":
+		for i := range v.entries {
+			v.expanded[i] = key == "e"
+		}
+		v.layout()
+	case "1", "2", "3":
+		role := browseRoles[key[0]-'1']
+		v.hiddenRole[role] = !v.hiddenRole[role]
+		v.layout()
+	case "t":
+		filter := v.toolFilter
+		b.input = &filter
+	case "T":
+		v.toolFilter = ""
+		v.layout()
+	case "q", "esc", "h", "left":
+		b.view = nil
+	}
+}
+
+// newSessionView builds the message view of the active branch of a session
+func newSessionView(session projects.Session, entries []*transcript.Entry, width, height int) *sessionView {
+	v := &sessionView{
+		session:    session,
+		entries:    transcript.BuildTree(entries).ActiveEntries(entries),
+		tools:      transcript.NewToolIndex(entries),
+		expanded:   make(map[int]bool),
+		hiddenRole: make(map[string]bool),
+		width:      width,
+		height:     height,
+	}
+	v.layout()
+	return v
+}
+
+// shownRoles returns the roles not toggled off
+func (v *sessionView) shownRoles() []string {
+	var roles []string
+	for _, role := range browseRoles {
+		if !v.hiddenRole[role] {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// roleFilter converts the role toggles to a --role value.
+// The caller handles all roles being hidden, which no --role value expresses.
+func (v *sessionView) roleFilter() string {
+	roles := v.shownRoles()
+	if len(roles) == len(browseRoles) {
+		return ""
+	}
+	return strings.Join(roles, ",")
+}
+
+// renderEntry renders an entry with the regular display functions.
+// Tool calls and results are shown compact unless expanded.
+func (v *sessionView) renderEntry(i int) []string {
+	// With every role hidden there is nothing to render
+	if len(v.shownRoles()) == 0 {
+		return nil
+	}
+
+	entry := v.entries[i]
+	var buf bytes.Buffer
+	opts := displayOptions{
+		w:       &buf,
+		filter:  newEntryFilter(v.roleFilter(), v.toolFilter, ""),
+		compact: isToolEntry(entry) && !v.expanded[i],
+	}
+	displayEntryWithOptions(entry, v.tools, opts)
+
+	text := strings.TrimSuffix(buf.String(), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// isToolEntry reports whether an entry carries tool calls or results
+func isToolEntry(entry *transcript.Entry) bool {
+	return entry.Message != nil &&
+		(entry.Message.Content.HasType(transcript.BlockToolUse) || entry.HasToolResult())
+}
+
+// layout renders all entries into screen lines, keeping the cursor on the same entry
+func (v *sessionView) layout() {
+	cursorEntry := -1
+	if v.cursor < len(v.visible) {
+		cursorEntry = v.visible[v.cursor]
+	}
+
+	v.lines = v.lines[:0]
+	v.visible = v.visible[:0]
+	v.starts = v.starts[:0]
+	v.position = make(map[int]int)
+
+	textWidth := v.width - 2 // Room for the cursor gutter
+	for i := range v.entries {
+		rendered := v.renderEntry(i)
+		if rendered == nil {
+			continue
+		}
+		v.position[i] = len(v.visible)
+		v.visible = append(v.visible, i)
+		v.starts = append(v.starts, len(v.lines))
+		for _, line := range rendered {
+			for _, wrapped := range wrapANSI(line, textWidth) {
+				v.lines = append(v.lines, viewLine{text: wrapped, entry: i})
+			}
+		}
+	}
+
+	v.cursor = 0
+	for pos, i := range v.visible {
+		if i >= cursorEntry {
+			v.cursor = pos
+			break
+		}
+	}
+	v.moveCursor(v.cursor)
+}
+
+// bodyHeight is the number of message lines on screen
+func (v *sessionView) bodyHeight() int {
+	return max(v.height-2, 1) // Header and footer
+}
+
+// entryEnd returns the line after the last line of the visible entry at pos
+func (v *sessionView) entryEnd(pos int) int {
+	if pos+1 < len(v.starts) {
+		return v.starts[pos+1]
+	}
+	return len(v.lines)
+}
+
+// moveCursor selects the visible entry at pos and scrolls it into view
+func (v *sessionView) moveCursor(pos int) {
+	if len(v.visible) == 0 {
+		v.cursor, v.offset = 0, 0
+		return
+	}
+	v.cursor = clamp(pos, 0, len(v.visible)-1)
+
+	start, end := v.starts[v.cursor], v.entryEnd(v.cursor)
+	body := v.bodyHeight()
+	if end > v.offset+body {
+		v.offset = end - body
+	}
+	if start < v.offset || end-start > body {
+		v.offset = start
+	}
+	v.offset = clamp(v.offset, 0, max(len(v.lines)-body, 0))
+}
+
+// scroll moves the view by n lines and selects the entry at the top
+func (v *sessionView) scroll(n int) {
+	if len(v.lines) == 0 {
+		return
+	}
+	v.offset = clamp(v.offset+n, 0, max(len(v.lines)-v.bodyHeight(), 0))
+	v.cursor = v.position[v.lines[v.offset].entry]
+	// Keep the cursor on the first entry starting on screen when possible
+	if v.starts[v.cursor] < v.offset && v.cursor+1 < len(v.visible) &&
+		v.starts[v.cursor+1] < v.offset+v.bodyHeight() {
+		v.cursor++
+	}
+}
+
+// jumpPrompt moves the cursor to the next (dir > 0) or previous user prompt
+func (v *sessionView) jumpPrompt(dir int) {
+	for pos := v.cursor + dir; pos >= 0 && pos < len(v.visible); pos += dir {
+		if v.entries[v.visible[pos]].IsPrompt() {
+			v.moveCursor(pos)
+			// Show the prompt at the top of the screen
+			v.offset = clamp(v.starts[pos], 0, max(len(v.lines)-v.bodyHeight(), 0))
+			return
+		}
+	}
+}
+
+// listHeight is the number of session rows on screen
+func (b *browser) listHeight() int {
+	return max(b.height-2, 1)
+}
+
+// draw renders the current screen
+func (b *browser) draw(w io.Writer) {
+	var frame strings.Builder
+	frame.WriteString(escHome)
+	if b.view != nil {
+		b.drawView(&frame)
+	} else {
+		b.drawList(&frame)
+	}
+	_, _ = io.WriteString(w, frame.String())
+}
+
+// drawBar writes a full-width reverse video bar
+func (b *browser) drawBar(frame *strings.Builder, text string, last bool) {
+	text = truncateDisplay(text, b.width)
+	frame.WriteString(escReverse + text + strings.Repeat(" ", max(b.width-displayWidth(text), 0)) + colorReset)
+	if !last {
+		frame.WriteString("\r\n")
+	}
+}
+
+// drawList renders the session list
+func (b *browser) drawList(frame *strings.Builder) {
+	b.drawBar(frame, fmt.Sprintf(" ccl browse - %d sessions", len(b.sessions)), false)
+
+	nameWidth := 0
+	for _, s := range b.sessions {
+		nameWidth = max(nameWidth, displayWidth(s.Display))
+	}
+	nameWidth = min(nameWidth, max(b.width/2, 10))
+
+	for row := 0; row < b.listHeight(); row++ {
+		i := b.listOffset + row
+		if i < len(b.sessions) {
+			s := b.sessions[i]
+			marker := " "
+			if s.Current {
+				marker = "*"
+			}
+			name := truncateDisplay(s.Display, nameWidth)
+			line := fmt.Sprintf("%s %s%s  %s  %7s  %s", marker,
+				name, strings.Repeat(" ", nameWidth-displayWidth(name)),
+				s.ModTime.Format("2006-01-02 15:04"), formatFileSize(s.Size), s.Path)
+			line = truncateDisplay(line, b.width)
+			if i == b.listCursor {
+				frame.WriteString(escReverse + line + strings.Repeat(" ", max(b.width-displayWidth(line), 0)) + colorReset)
+			} else {
+				frame.WriteString(line)
+			}
+		}
+		frame.WriteString(escClearLine + "\r\n")
+	}
+
+	if b.err != nil {
+		b.drawBar(frame, " Error: "+b.err.Error(), true)
+		return
+	}
+	b.drawBar(frame, " j/k move  enter open  q quit", true)
+}
+
+// drawView renders the message view of the open session
+func (b *browser) drawView(frame *strings.Builder) {
+	v := b.view
+	b.drawBar(frame, fmt.Sprintf(" %s  %s", v.session.Display, v.session.Path), false)
+
+	cursorEntry := -1
+	if v.cursor < len(v.visible) {
+		cursorEntry = v.visible[v.cursor]
+	}
+	for row := 0; row < v.bodyHeight(); row++ {
+		i := v.offset + row
+		if i < len(v.lines) {
+			line := v.lines[i]
+			if line.entry == cursorEntry {
+				frame.WriteString(color(colorCyan) + "▌" + color(colorReset) + " ")
+			} else {
+				frame.WriteString("  ")
+			}
+			frame.WriteString(line.text + colorReset)
+		}
+		frame.WriteString(escClearLine + "\r\n")
+	}
+
+	if b.input != nil {
+		b.drawBar(frame, " Tool filter: "+*b.input+"_", true)
+		return
+	}
+
+	var toggles []string
+	for n, role := range browseRoles {
+		state := "+"
+		if v.hiddenRole[role] {
+			state = "-"
+		}
+		toggles = append(toggles, fmt.Sprintf("%d%s%s", n+1, state, role))
+	}
+	status := " " + strings.Join(toggles, " ")
+	if v.toolFilter != "" {
+		status += "  tool:" + v.toolFilter
+	}
+	if len(v.visible) > 0 {
+		status += fmt.Sprintf("  [%d/%d]", v.cursor+1, len(v.visible))
+	}
+	status += "  j/k n/p enter e/c t q"
+	b.drawBar(frame, status, true)
+}
+
+// clamp limits n to the range [lo, hi]
+func clamp(n, lo, hi int) int {
+	if n > hi {
+		n = hi
+	}
+	if n < lo {
+		n = lo
+	}
+	return n
+}
+
+// runeWidth returns the number of terminal columns a rune occupies
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x20:
+		return 0
+	case r >= 0x1100 && r <= 0x115f, // Hangul Jamo
+		r >= 0x2e80 && r <= 0xa4cf && r != 0x303f, // CJK, Kana, Yi
+		r >= 0xac00 && r <= 0xd7a3,                // Hangul syllables
+		r >= 0xf900 && r <= 0xfaff,                // CJK compatibility ideographs
+		r >= 0xfe30 && r <= 0xfe4f,                // CJK compatibility forms
+		r >= 0xff00 && r <= 0xff60,                // Fullwidth forms
+		r >= 0xffe0 && r <= 0xffe6,
+		r >= 0x1f300 && r <= 0x1f64f, // Emoji
+		r >= 0x1f900 && r <= 0x1f9ff,
+		r >= 0x20000 && r <= 0x3fffd:
+		return 2
+	}
+	return 1
+}
+
+// displayWidth returns the terminal width of a string without escape sequences
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// truncateDisplay cuts a plain string to at most width columns
+func truncateDisplay(s string, width int) string {
+	used := 0
+	for i, r := range s {
+		w := runeWidth(r)
+		if used+w > width {
+			return s[:i]
+		}
+		used += w
+	}
+	return s
+}
+
+// wrapANSI splits a line containing color escape sequences into lines of at
+// most width columns, carrying the active colors over to continuation lines
+func wrapANSI(line string, width int) []string {
+	if width < 1 {
+		width = 1
+	}
+
+	var lines []string
+	var current strings.Builder
+	active := "" // SGR sequences in effect since the last reset
+	col := 0
+
+	newLine := func() {
+		if active != "" {
+			current.WriteString(colorReset)
+		}
+		lines = append(lines, current.String())
+		current.Reset()
+		current.WriteString(active)
+		col = 0
+	}
+
+	for i := 0; i < len(line); {
+		// Copy escape sequences through without counting them
+		if line[i] == 0x1b && i+1 < len(line) && line[i+1] == '[' {
+			end := i + 2
+			for end < len(line) && (line[end] < 0x40 || line[end] > 0x7e) {
+				end++
+			}
+			if end < len(line) {
+				end++
+			}
+			seq := line[i:end]
+			current.WriteString(seq)
+			if strings.HasSuffix(seq, "m") {
+				if seq == colorReset || seq == "\033[m" {
+					active = ""
+				} else {
+					active += seq
+				}
+			}
+			i = end
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(line[i:])
+		i += size
+
+		switch r {
+		case '\t':
+			spaces := 8 - col%8
+			if col+spaces > width {
+				newLine()
+				spaces = 8
+			}
+			current.WriteString(strings.Repeat(" ", min(spaces, width-col)))
+			col += min(spaces, width-col)
+			continue
+		case '\r':
+			continue
+		}
+
+		w := runeWidth(r)
+		if w == 0 {
+			continue
+		}
+		if col+w > width {
+			newLine()
+		}
+		current.WriteRune(r)
+		col += w
+	}
+	lines = append(lines, current.String())
+	return lines
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Sixeight/ccl/projects"
+	"github.com/Sixeight/ccl/transcript"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected []string
+	}{
+		"letters":          {"jk", []string{"j", "k"}},
+		"arrows":           {"\x1b[A\x1b[B", []string{"up", "down"}},
+		"page keys":        {"\x1b[5~\x1b[6~", []string{"pgup", "pgdn"}},
+		"lone escape":      {"\x1b", []string{"esc"}},
+		"enter and ctrl-c": {"\r\x03", []string{"enter", "ctrl-c"}},
+		"multibyte":        {"あ", []string{"あ"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := parseKeys([]byte(tt.input)); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("parseKeys(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWrapANSI(t *testing.T) {
+	tests := map[string]struct {
+		line     string
+		width    int
+		expected []string
+	}{
+		"fits": {
+			line:     "hello",
+			width:    10,
+			expected: []string{"hello"},
+		},
+		"wraps plain text": {
+			line:     "abcdefgh",
+			width:    3,
+			expected: []string{"abc", "def", "gh"},
+		},
+		"wide runes": {
+			line:     "日本語です",
+			width:    4,
+			expected: []string{"日本", "語で", "す"},
+		},
+		"colors carry over": {
+			line:     colorRed + "abcd" + colorReset + "ef",
+			width:    2,
+			expected: []string{colorRed + "ab" + colorReset, colorRed + "cd" + colorReset, "ef"},
+		},
+		"tabs expand": {
+			line:     "1\tx",
+			width:    20,
+			expected: []string{"1       x"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := wrapANSI(tt.line, tt.width); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("wrapANSI(%q, %d) = %q; want %q", tt.line, tt.width, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSessionView(t *testing.T) {
+	origCfg := cfg
+	defer func() { cfg = origCfg }()
+	cfg.NoColor = true
+
+	input := strings.Join([]string{
+		`{"type":"user","uuid":"u1","message":{"content":"First prompt"}}`,
+		`{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"content":[{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"ls"}}]}}`,
+		`{"type":"user","uuid":"r1","parentUuid":"a1","message":{"content":[{"type":"tool_result","tool_use_id":"t1","content":"a\nb\nc"}]}}`,
+		`{"type":"user","uuid":"u2","parentUuid":"r1","message":{"content":"Second prompt"}}`,
+	}, "\n")
+	entries, err := transcript.ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	v := newSessionView(projects.Session{Path: "s.jsonl"}, entries, 80, 40)
+	if len(v.visible) != 4 {
+		t.Fatalf("visible entries = %d; want 4", len(v.visible))
+	}
+
+	// Tool calls start collapsed to a single compact line
+	collapsed := v.entryEnd(2) - v.starts[2]
+	v.moveCursor(2)
+	b := &browser{view: v, width: 80, height: 40}
+	b.handleKey("enter")
+	if expanded := v.entryEnd(2) - v.starts[2]; expanded <= collapsed {
+		t.Errorf("expanded tool result has %d lines; want more than %d", expanded, collapsed)
+	}
+	if v.cursor != 2 {
+		t.Errorf("cursor moved to %d after expanding; want 2", v.cursor)
+	}
+
+	// Jump between prompts
+	b.handleKey("n")
+	if v.visible[v.cursor] != 3 {
+		t.Errorf("next prompt = entry %d; want 3", v.visible[v.cursor])
+	}
+	b.handleKey("p")
+	if v.visible[v.cursor] != 0 {
+		t.Errorf("previous prompt = entry %d; want 0", v.visible[v.cursor])
+	}
+
+	// Hide tool results, then filter by tool
+	b.handleKey("3")
+	if len(v.visible) != 3 {
+		t.Errorf("visible entries without tool results = %d; want 3", len(v.visible))
+	}
+	b.handleKey("3")
+
+	// Hiding every role hides every entry
+	for _, key := range []string{"1", "2", "3"} {
+		b.handleKey(key)
+	}
+	if len(v.visible) != 0 {
+		t.Errorf("visible entries with all roles hidden = %d; want 0", len(v.visible))
+	}
+	for _, key := range []string{"1", "2", "3"} {
+		b.handleKey(key)
+	}
+
+	for _, key := range []string{"t", "B", "a", "s", "h", "enter"} {
+		b.handleKey(key)
+	}
+	if v.toolFilter != "Bash" || len(v.visible) != 2 {
+		t.Errorf("tool filter %q shows %d entries; want Bash with 2", v.toolFilter, len(v.visible))
+	}
+}
+
+func TestBrowserOpenError(t *testing.T) {
+	origCfg := cfg
+	defer func() { cfg = origCfg }()
+	cfg.NoColor = true
+
+	path := filepath.Join(t.TempDir(), "missing.jsonl")
+	b := &browser{sessions: []projects.Session{{Path: path}}, width: 80, height: 10}
+	b.handleKey("enter")
+	if b.view != nil || b.err == nil {
+		t.Fatalf("opening a missing session: view = %v, err = %v; want an error", b.view, b.err)
+	}
+
+	var screen strings.Builder
+	b.draw(&screen)
+	if !strings.Contains(screen.String(), "Error: open "+path) {
+		t.Errorf("screen does not show the error: %q", screen.String())
+	}
+
+	// The next key clears it
+	b.handleKey("j")
+	if b.err != nil {
+		t.Errorf("err = %v after a key; want nil", b.err)
+	}
+}
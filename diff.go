@@ -0,0 +1,323 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+// Lines of unchanged context kept around each change
+const diffContext = 3
+
+// Above this many line pairs, texts are diffed as a whole replacement
+const maxDiffCells = 4000000
+
+// Lines of a Write diff shown before truncating
+const maxWriteDiffLines = 20
+
+// diffHunk is a block of changed lines, in the shape of the structuredPatch
+// field Claude Code records for Edit, MultiEdit and Write results.
+// A hunk with both starts zero has an unknown position in the file.
+type diffHunk struct {
+	OldStart int      `json:"oldStart"`
+	OldLines int      `json:"oldLines"`
+	NewStart int      `json:"newStart"`
+	NewLines int      `json:"newLines"`
+	Lines    []string `json:"lines"` // Each prefixed with ' ', '-' or '+'
+}
+
+// header returns the @@ line of the hunk
+func (h diffHunk) header() string {
+	if h.OldStart == 0 && h.NewStart == 0 {
+		return "@@"
+	}
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+}
+
+// hunkRange formats a start,count pair the way diff -u does
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits text into lines, ignoring a final newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines returns the lines of a and b prefixed with ' ', '-' or '+',
+// based on their longest common subsequence
+func diffLines(a, b []string) []string {
+	// Common prefix and suffix need no table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var lines []string
+	for _, line := range a[:prefix] {
+		lines = append(lines, " "+line)
+	}
+	lines = append(lines, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		lines = append(lines, " "+line)
+	}
+	return lines
+}
+
+// diffMiddle diffs the differing middle part of two texts
+func diffMiddle(a, b []string) []string {
+	var lines []string
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			lines = append(lines, "-"+line)
+		}
+		for _, line := range b {
+			lines = append(lines, "+"+line)
+		}
+		return lines
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, " "+a[i])
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			lines = append(lines, "+"+b[j])
+			j++
+		default:
+			lines = append(lines, "-"+a[i])
+			i++
+		}
+	}
+	return lines
+}
+
+// diffHunks diffs two texts into hunks with context lines around each change.
+// Line numbers count from the start of the texts.
+func diffHunks(oldText, newText string, context int) []diffHunk {
+	return diffLineHunks(splitLines(oldText), splitLines(newText), context)
+}
+
+// diffLineHunks diffs two lists of lines into hunks with context lines
+// around each change
+func diffLineHunks(a, b []string, context int) []diffHunk {
+	lines := diffLines(a, b)
+
+	// oldAt[i] and newAt[i] count the old and new lines before lines[i]
+	oldAt := make([]int, len(lines)+1)
+	newAt := make([]int, len(lines)+1)
+	for i, line := range lines {
+		oldAt[i+1], newAt[i+1] = oldAt[i], newAt[i]
+		if line[0] != '+' {
+			oldAt[i+1]++
+		}
+		if line[0] != '-' {
+			newAt[i+1]++
+		}
+	}
+
+	var hunks []diffHunk
+	for i := 0; i < len(lines); {
+		if lines[i][0] == ' ' {
+			i++
+			continue
+		}
+
+		// Join changes separated by no more than twice the context
+		last := i
+		for j := i + 1; j < len(lines) && j-last <= 2*context+1; j++ {
+			if lines[j][0] != ' ' {
+				last = j
+			}
+		}
+		start := max(i-context, 0)
+		end := min(last+1+context, len(lines))
+
+		hunk := diffHunk{
+			OldStart: oldAt[start] + 1,
+			OldLines: oldAt[end] - oldAt[start],
+			NewStart: newAt[start] + 1,
+			NewLines: newAt[end] - newAt[start],
+			Lines:    lines[start:end],
+		}
+		// An empty range starts at the line before it, as in diff -u
+		if hunk.OldLines == 0 {
+			hunk.OldStart--
+		}
+		if hunk.NewLines == 0 {
+			hunk.NewStart--
+		}
+		hunks = append(hunks, hunk)
+		i = end
+	}
+	return hunks
+}
+
+// structuredPatch decodes the structuredPatch field of a tool result
+func structuredPatch(toolUseResult map[string]interface{}) []diffHunk {
+	raw, ok := toolUseResult["structuredPatch"]
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var hunks []diffHunk
+	if err := json.Unmarshal(data, &hunks); err != nil {
+		return nil
+	}
+	return hunks
+}
+
+// editHunk renders one Edit replacement as a hunk of unknown position
+func editHunk(edit transcript.FileEdit) (diffHunk, bool) {
+	if edit.OldString == "" && edit.NewString == "" {
+		return diffHunk{}, false
+	}
+	return diffHunk{Lines: diffLines(splitLines(edit.OldString), splitLines(edit.NewString))}, true
+}
+
+// toolDiffHunks returns the changes an Edit, MultiEdit or Write call makes.
+// The structured patch of its result is preferred, since it carries line numbers.
+func toolDiffHunks(tool *transcript.ContentBlock, result *transcript.Entry) ([]diffHunk, bool) {
+	if result != nil {
+		if hunks := structuredPatch(result.ToolUseResultMap()); len(hunks) > 0 {
+			return hunks, true
+		}
+	}
+
+	input := tool.FileInput()
+	switch tool.Name {
+	case "Edit":
+		if hunk, ok := editHunk(input.FileEdit); ok {
+			return []diffHunk{hunk}, true
+		}
+	case "MultiEdit":
+		var hunks []diffHunk
+		for _, edit := range input.Edits {
+			if hunk, ok := editHunk(edit); ok {
+				hunks = append(hunks, hunk)
+			}
+		}
+		return hunks, len(hunks) > 0
+	case "Write":
+		if input.Content == "" {
+			return nil, false
+		}
+		hunk := diffHunk{}
+		for _, line := range splitLines(input.Content) {
+			hunk.Lines = append(hunk.Lines, "+"+line)
+		}
+		// A created file is known to start from nothing
+		if result != nil && result.ToolUseResultMap()["type"] == "create" {
+			hunk.NewStart, hunk.NewLines = 1, len(hunk.Lines)
+		}
+		return []diffHunk{hunk}, true
+	}
+	return nil, false
+}
+
+// isDiffTool reports whether a tool's input is shown as a diff
+func isDiffTool(name string) bool {
+	return name == "Edit" || name == "MultiEdit" || name == "Write"
+}
+
+// displayToolDiff prints an Edit, MultiEdit or Write call as a colored
+// unified diff headed by its file path; it reports false when the input
+// has no recognizable changes
+func displayToolDiff(w io.Writer, tool *transcript.ContentBlock, indent string, tools transcript.ToolIndex) bool {
+	result, _ := tools.Result(tool.ID)
+	hunks, ok := toolDiffHunks(tool, result)
+	if !ok {
+		return false
+	}
+
+	input := tool.FileInput()
+	fmt.Fprintf(w, "%s%s%s%s", indent, color(colorBold), input.FilePath, color(colorReset))
+	if input.ReplaceAll {
+		fmt.Fprintf(w, " %s(replace all)%s", color(colorGray), color(colorReset))
+	}
+	fmt.Fprintln(w)
+
+	var lines []string
+	for _, hunk := range hunks {
+		lines = append(lines, color(colorCyan)+hunk.header()+color(colorReset))
+		for _, line := range hunk.Lines {
+			lines = append(lines, colorDiffLine(line))
+		}
+	}
+
+	// Whole-file writes are cut short like other long output;
+	// +2 so that only a couple of hidden lines are shown instead
+	shown := lines
+	if tool.Name == "Write" && len(lines) > maxWriteDiffLines+2 {
+		shown = lines[:maxWriteDiffLines]
+	}
+	for _, line := range shown {
+		fmt.Fprintf(w, "%s%s\n", indent, line)
+	}
+	if remaining := len(lines) - len(shown); remaining > 0 {
+		fmt.Fprintf(w, "%s%s... (%d more lines)%s\n", indent, color(colorGray), remaining, color(colorReset))
+	}
+	return true
+}
+
+// colorDiffLine colors a diff line by its prefix
+func colorDiffLine(line string) string {
+	if line == "" {
+		return line
+	}
+	switch line[0] {
+	case '-':
+		return color(colorRed) + line + color(colorReset)
+	case '+':
+		return color(colorGreen) + line + color(colorReset)
+	}
+	return line
+}
+
+// formatHunks renders hunks as plain unified diff text
+func formatHunks(hunks []diffHunk) string {
+	var b strings.Builder
+	for _, hunk := range hunks {
+		b.WriteString(hunk.header())
+		b.WriteByte('\n')
+		for _, line := range hunk.Lines {
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+func TestDiffHunks(t *testing.T) {
+	numbered := func(from, to int) []string {
+		var lines []string
+		for i := from; i <= to; i++ {
+			lines = append(lines, string(rune('a'+i-1)))
+		}
+		return lines
+	}
+	oldText := strings.Join(numbered(1, 20), "\n") + "\n"
+
+	tests := map[string]struct {
+		oldText  string
+		newText  string
+		expected []diffHunk
+	}{
+		"no change": {oldText, oldText, nil},
+		"single replacement": {
+			oldText, strings.Replace(oldText, "j\n", "J\n", 1),
+			[]diffHunk{{OldStart: 7, OldLines: 7, NewStart: 7, NewLines: 7,
+				Lines: []string{" g", " h", " i", "-j", "+J", " k", " l", " m"}}},
+		},
+		"distant changes split": {
+			oldText, strings.Replace(strings.Replace(oldText, "b\n", "", 1), "s\n", "s\nS\n", 1),
+			[]diffHunk{
+				{OldStart: 1, OldLines: 5, NewStart: 1, NewLines: 4, Lines: []string{" a", "-b", " c", " d", " e"}},
+				{OldStart: 17, OldLines: 4, NewStart: 16, NewLines: 5, Lines: []string{" q", " r", " s", "+S", " t"}}},
+		},
+		"close changes joined": {
+			oldText, strings.Replace(strings.Replace(oldText, "c\n", "C\n", 1), "i\n", "I\n", 1),
+			[]diffHunk{{OldStart: 1, OldLines: 12, NewStart: 1, NewLines: 12,
+				Lines: []string{" a", " b", "-c", "+C", " d", " e", " f", " g", " h", "-i", "+I", " j", " k", " l"}}},
+		},
+		"new file": {
+			"", "x\ny\n",
+			[]diffHunk{{OldStart: 0, OldLines: 0, NewStart: 1, NewLines: 2, Lines: []string{"+x", "+y"}}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := diffHunks(tt.oldText, tt.newText, diffContext); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("diffHunks() = %+v; want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestToolDiffHunks(t *testing.T) {
+	input := strings.Join([]string{
+		`{"type":"assistant","message":{"content":[` +
+			`{"type":"tool_use","id":"t1","name":"Edit","input":{"file_path":"a.go","old_string":"x := 1\nreturn x","new_string":"x := 2\nreturn x"}},` +
+			`{"type":"tool_use","id":"t2","name":"MultiEdit","input":{"file_path":"b.go","edits":[{"old_string":"a","new_string":"A"},{"old_string":"b","new_string":"B"}]}},` +
+			`{"type":"tool_use","id":"t3","name":"Edit","input":{"file_path":"c.go","old_string":"old","new_string":"new"}}]}}`,
+		`{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"t3","content":"updated"}]},` +
+			`"toolUseResult":{"structuredPatch":[{"oldStart":10,"oldLines":1,"newStart":10,"newLines":1,"lines":["-old","+new"]}]}}`,
+	}, "\n")
+	entries, err := transcript.ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	tools := transcript.NewToolIndex(entries)
+
+	tests := map[string]struct {
+		id       string
+		expected []diffHunk
+	}{
+		"edit from input": {"t1", []diffHunk{{Lines: []string{"-x := 1", "+x := 2", " return x"}}}},
+		"multiedit hunk per edit": {"t2", []diffHunk{
+			{Lines: []string{"-a", "+A"}},
+			{Lines: []string{"-b", "+B"}},
+		}},
+		"structured patch preferred": {"t3", []diffHunk{
+			{OldStart: 10, OldLines: 1, NewStart: 10, NewLines: 1, Lines: []string{"-old", "+new"}},
+		}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			result, _ := tools.Result(tt.id)
+			got, ok := toolDiffHunks(tools[tt.id].Block, result)
+			if !ok || !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("toolDiffHunks() = %+v, %v; want %+v", got, ok, tt.expected)
+			}
+		})
+	}
+}
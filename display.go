@@ -3,14 +3,21 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/Sixeight/ccl/transcript"
 )
 
 // Global state for tracking timing
 var lastTimestamp time.Time
 
+// output is where entries are rendered
+var output io.Writer = os.Stdout
+
 // Color codes
 const (
 	colorReset  = "\033[0m"
@@ -45,99 +52,86 @@ func formatTimestamp(timestamp string) string {
 	if cfg.ShowTiming && !lastTimestamp.IsZero() {
 		elapsed := localTime.Sub(lastTimestamp)
 		lastTimestamp = localTime
-
-		// Format elapsed time
-		var elapsedStr string
-		switch {
-		case elapsed < time.Second:
-			elapsedStr = fmt.Sprintf("+%dms", elapsed.Milliseconds())
-		case elapsed < time.Minute:
-			elapsedStr = fmt.Sprintf("+%.1fs", elapsed.Seconds())
-		default:
-			elapsedStr = fmt.Sprintf("+%dm%ds", int(elapsed.Minutes()), int(elapsed.Seconds())%60)
-		}
-		return fmt.Sprintf("%s %s", localTime.Format("15:04:05"), elapsedStr)
+		return fmt.Sprintf("%s +%s", localTime.Format("15:04:05"), formatElapsed(elapsed))
 	}
 
 	lastTimestamp = localTime
 	return localTime.Format("15:04:05")
 }
 
-// Extract content array from message
-func extractContent(message map[string]interface{}) []map[string]interface{} {
-	var result []map[string]interface{}
-
-	// Handle string content (for regular user messages)
-	if content, ok := message["content"].(string); ok {
-		result = append(result, map[string]interface{}{
-			"type": "text",
-			"text": content,
-		})
-		return result
+// formatElapsed formats a short duration such as 850ms, 2.5s or 1m30s
+func formatElapsed(elapsed time.Duration) string {
+	switch {
+	case elapsed < time.Second:
+		return fmt.Sprintf("%dms", elapsed.Milliseconds())
+	case elapsed < time.Minute:
+		return fmt.Sprintf("%.1fs", elapsed.Seconds())
+	default:
+		return fmt.Sprintf("%dm%ds", int(elapsed.Minutes()), int(elapsed.Seconds())%60)
 	}
+}
 
-	// Handle array content
-	if content, ok := message["content"].([]interface{}); ok {
-		for _, item := range content {
-			if m, ok := item.(map[string]interface{}); ok {
-				result = append(result, m)
-			}
-		}
+// toolLatency returns the time from a tool call to its result
+func toolLatency(call, result *transcript.Entry) (time.Duration, bool) {
+	if call == nil || result == nil {
+		return 0, false
 	}
-
-	return result
+	start, ok := call.Time()
+	if !ok {
+		return 0, false
+	}
+	end, ok := result.Time()
+	if !ok || end.Before(start) {
+		return 0, false
+	}
+	return end.Sub(start), true
 }
 
 // Get brief summary of message for compact mode
-func getMessageSummary(message map[string]interface{}) string {
-	content := extractContent(message)
-	if len(content) == 0 {
+func getMessageSummary(message *transcript.Message) string {
+	if message == nil || len(message.Content) == 0 {
 		return ""
 	}
 
 	var parts []string
-	for _, item := range content {
-		switch item["type"] {
-		case "text":
-			if text, ok := item["text"].(string); ok {
-				// Take first line or 60 runes (for proper UTF-8 handling)
-				lines := strings.Split(text, "\n")
-				firstLine := strings.TrimSpace(lines[0])
-				summary := truncateRunes(firstLine, 60)
-				parts = append(parts, summary)
+	for i := range message.Content {
+		item := &message.Content[i]
+		switch item.Type {
+		case transcript.BlockText:
+			// Take first line or 60 runes (for proper UTF-8 handling)
+			lines := strings.Split(item.Text, "\n")
+			firstLine := strings.TrimSpace(lines[0])
+			summary := truncateRunes(firstLine, 60)
+			parts = append(parts, summary)
+		case transcript.BlockToolUse:
+			if item.Name == "" {
+				continue
 			}
-		case "tool_use":
-			if name, ok := item["name"].(string); ok {
-				toolSummary := fmt.Sprintf("[Tool: %s]", name)
+			toolSummary := fmt.Sprintf("[Tool: %s]", item.Name)
+			if item.Name == "Bash" {
 				// For Bash tool, include the command
-				if input, ok := item["input"].(map[string]interface{}); ok {
-					if name == "Bash" {
-						if cmd, ok := input["command"].(string); ok {
-							// Remove newlines and truncate command
-							cmd = strings.ReplaceAll(cmd, "\n", " ")
-							cmd = truncateRunes(strings.TrimSpace(cmd), 40)
-							toolSummary = fmt.Sprintf("[Tool: Bash] %s", cmd)
-						}
-					} else {
-						// Check for file_path in other tools
-						if filePath, ok := input["file_path"].(string); ok {
-							toolSummary = fmt.Sprintf("[Tool: %s] %s", name, filePath)
-						}
-					}
+				if cmd := item.BashInput().Command; cmd != "" {
+					// Remove newlines and truncate command
+					cmd = strings.ReplaceAll(cmd, "\n", " ")
+					cmd = truncateRunes(strings.TrimSpace(cmd), 40)
+					toolSummary = fmt.Sprintf("[Tool: Bash] %s", cmd)
 				}
-				parts = append(parts, toolSummary)
+			} else if filePath := item.FileInput().FilePath; filePath != "" {
+				// Check for file_path in other tools
+				toolSummary = fmt.Sprintf("[Tool: %s] %s", item.Name, filePath)
+			}
+			parts = append(parts, toolSummary)
+		case transcript.BlockThinking, transcript.BlockRedactedThinking:
+			if !logConfig.noThinking {
+				parts = append(parts, thinkingSummary(item))
 			}
-		case "tool_result":
+		case transcript.BlockToolResult:
 			// Show tool result summary
-			if content, ok := item["content"].(string); ok {
-				lines := strings.Split(content, "\n")
-				if len(lines) > 0 && lines[0] != "" {
-					firstLine := strings.TrimSpace(lines[0])
-					summary := truncateRunes(firstLine, 40)
-					parts = append(parts, fmt.Sprintf("[Result: %s]", summary))
-				} else {
-					parts = append(parts, "[Tool Result]")
-				}
+			lines := strings.Split(item.Content.Text(), "\n")
+			if lines[0] != "" {
+				firstLine := strings.TrimSpace(lines[0])
+				summary := truncateRunes(firstLine, 40)
+				parts = append(parts, fmt.Sprintf("[Result: %s]", summary))
 			} else {
 				parts = append(parts, "[Tool Result]")
 			}
@@ -147,257 +141,240 @@ func getMessageSummary(message map[string]interface{}) string {
 	return strings.Join(parts, " ")
 }
 
+// displayOptions are the settings an entry is rendered with
+type displayOptions struct {
+	w       io.Writer // Where the entry is written
+	filter  entryFilter
+	compact bool
+}
+
+// cfgDisplayOptions returns the display settings given on the command line
+func cfgDisplayOptions() displayOptions {
+	return displayOptions{w: output, filter: cfgEntryFilter(), compact: cfg.Compact}
+}
+
 // Display entry with tool information
-func displayEntryWithToolInfo(entry map[string]interface{}, toolUseMap map[string]string, toolInputMap map[string]map[string]interface{}) {
-	msgType, _ := entry["type"].(string)
-	timestamp, _ := entry["timestamp"].(string)
-	version, _ := entry["version"].(string)
+func displayEntryWithToolInfo(entry *transcript.Entry, tools transcript.ToolIndex) {
+	displayEntryWithOptions(entry, tools, cfgDisplayOptions())
+}
 
+// displayEntryWithOptions displays an entry with explicit filters and layout
+func displayEntryWithOptions(entry *transcript.Entry, tools transcript.ToolIndex, opts displayOptions) {
 	// Check if this entry should be displayed based on filters
-	if !shouldDisplayEntryWithToolInfo(msgType, entry, toolUseMap) {
+	if !opts.filter.match(entry.Type, entry, tools) {
 		return
 	}
 
 	// JSON output mode
 	if cfg.OutputFormat == "json" {
-		displayEntryAsJSON(entry, toolUseMap)
+		displayEntryAsJSON(opts.w, entry)
 		return
 	}
 
 	// Format timestamp and version info
-	timeStr := formatTimestamp(timestamp)
-	versionStr := formatVersionInfo(version)
+	timeStr := formatTimestamp(entry.Timestamp)
+	versionStr := formatVersionInfo(entry.Version, opts.compact)
 
 	// Route to appropriate display function
 	// Note: "tool" type doesn't exist in the data, tool results are in "user" messages
-	switch msgType {
+	switch entry.Type {
 	case "user":
-		displayUserMessage(entry, timeStr, versionStr, toolUseMap, toolInputMap)
+		displayUserMessage(opts.w, entry, timeStr, versionStr, tools, opts.compact)
 	case "assistant":
-		displayAssistantMessage(entry, timeStr, versionStr)
+		displayAssistantMessage(opts.w, entry, timeStr, versionStr, tools, opts.compact)
 	}
 }
 
 // Format version info for display
-func formatVersionInfo(version string) string {
-	if version == "" || cfg.Compact {
+func formatVersionInfo(version string, compact bool) string {
+	if version == "" || compact {
 		return ""
 	}
 	return fmt.Sprintf(" %sv%s%s", color(colorGray), version, colorReset)
 }
 
 // Display user message
-func displayUserMessage(entry map[string]interface{}, timeStr, versionStr string, toolUseMap map[string]string, toolInputMap map[string]map[string]interface{}) {
-	message, ok := entry["message"].(map[string]interface{})
-	if !ok {
+func displayUserMessage(w io.Writer, entry *transcript.Entry, timeStr, versionStr string, tools transcript.ToolIndex, compact bool) {
+	message := entry.Message
+	if message == nil {
 		return
 	}
 
 	// Check if this is a tool result message
-	contents := extractContent(message)
-	isToolResult := false
-	for _, content := range contents {
-		if content["type"] == "tool_result" {
-			isToolResult = true
-			break
-		}
+	if message.Content.HasType(transcript.BlockToolResult) {
+		// Display as TOOL message
+		displayToolResultSimple(w, entry, timeStr, versionStr, tools, compact)
+		return
 	}
 
-	if isToolResult {
-		// Display as TOOL message
-		toolUseResult, _ := entry["toolUseResult"].(map[string]interface{})
-		displayToolResultSimple(message, timeStr, versionStr, toolUseMap, toolInputMap, toolUseResult)
-	} else {
-		// Check if this is a slash command
-		isSlashCommand := false
-		contents := extractContent(message)
-		for _, content := range contents {
-			if content["type"] == "text" {
-				if text, ok := content["text"].(string); ok {
-					// Slash commands are wrapped in <command-name> tags
-					if strings.Contains(text, "<command-name>") && strings.Contains(text, "</command-name>") {
-						isSlashCommand = true
-					}
-					break
-				}
-			}
+	// Check if this is a slash command
+	isSlashCommand := false
+	if text := message.Content.FirstOfType(transcript.BlockText); text != nil {
+		// Slash commands are wrapped in <command-name> tags
+		if strings.Contains(text.Text, "<command-name>") && strings.Contains(text.Text, "</command-name>") {
+			isSlashCommand = true
 		}
+	}
 
-		// Display as regular USER message
-		if !cfg.Compact {
-			fmt.Printf("%s[%s]%s %sUSER%s",
-				color(colorGray), timeStr, versionStr,
-				color(colorBlue+colorBold), colorReset)
+	// Display as regular USER message
+	if !compact {
+		fmt.Fprintf(w, "%s[%s]%s %sUSER%s",
+			color(colorGray), timeStr, versionStr,
+			color(colorBlue+colorBold), colorReset)
 
-			// Add [COMMAND] label for slash commands
-			if isSlashCommand {
-				fmt.Printf(" %s[COMMAND]%s", color(colorPurple), colorReset)
-			}
+		// Add [COMMAND] label for slash commands
+		if isSlashCommand {
+			fmt.Fprintf(w, " %s[COMMAND]%s", color(colorPurple), colorReset)
+		}
+
+		fmt.Fprintln(w)
+		displayMessageContent(w, message, "  ", tools)
+		fmt.Fprintln(w)
+	} else {
+		// Compact mode: fixed width role display
+		fmt.Fprintf(w, "%s[%s]%s %s%-9s%s - ",
+			color(colorGray), timeStr, colorReset,
+			color(colorBlue+colorBold), "USER", colorReset)
 
-			fmt.Println()
-			displayMessageContent(message, "  ")
-			fmt.Println()
+		summary := getMessageSummary(message)
+		if summary != "" {
+			fmt.Fprintf(w, "%s\n", summary)
 		} else {
-			// Compact mode: fixed width role display
-			fmt.Printf("%s[%s]%s %s%-9s%s - ",
-				color(colorGray), timeStr, colorReset,
-				color(colorBlue+colorBold), "USER", colorReset)
-
-			summary := getMessageSummary(message)
-			if summary != "" {
-				fmt.Printf("%s\n", summary)
-			} else {
-				fmt.Printf("\n")
-			}
+			fmt.Fprintf(w, "\n")
 		}
 	}
 }
 
 // Display assistant message
-func displayAssistantMessage(entry map[string]interface{}, timeStr, versionStr string) {
-	message, ok := entry["message"].(map[string]interface{})
-	if !ok {
+func displayAssistantMessage(w io.Writer, entry *transcript.Entry, timeStr, versionStr string, tools transcript.ToolIndex, compact bool) {
+	message := entry.Message
+	if message == nil {
 		return
 	}
 
 	// Display header
-	if !cfg.Compact {
-		fmt.Printf("%s[%s]%s %sASSISTANT%s",
+	if !compact {
+		fmt.Fprintf(w, "%s[%s]%s %sASSISTANT%s",
 			color(colorGray), timeStr, versionStr,
 			color(colorGreen+colorBold), colorReset)
 
 		// Check for model info
-		if model, ok := message["model"].(string); ok {
-			fmt.Printf(" %s(%s)%s", color(colorGray), model, colorReset)
+		if message.Model != "" {
+			fmt.Fprintf(w, " %s(%s)%s", color(colorGray), message.Model, colorReset)
 		}
 
 		// Display usage info if available
-		if usage, ok := message["usage"].(map[string]interface{}); ok {
+		if usage := message.Usage; usage != nil {
 			// Always show brief token info
-			if inputTokens, ok := getTokenCount(usage, "input_tokens"); ok {
-				if outputTokens, ok := getTokenCount(usage, "output_tokens"); ok {
-					fmt.Printf(" [↑%d ↓%d", inputTokens, outputTokens)
+			fmt.Fprintf(w, " [↑%d ↓%d", usage.InputTokens, usage.OutputTokens)
 
-					// Show cache info if available
-					if cacheRead, ok := getTokenCount(usage, "cache_read_input_tokens"); ok && cacheRead > 0 {
-						fmt.Printf(" *%d", cacheRead)
-					}
-					if cacheCreate, ok := getTokenCount(usage, "cache_creation_input_tokens"); ok && cacheCreate > 0 {
-						fmt.Printf(" +%d", cacheCreate)
-					}
+			// Show cache info if available
+			if usage.CacheReadInputTokens > 0 {
+				fmt.Fprintf(w, " *%d", usage.CacheReadInputTokens)
+			}
+			if usage.CacheCreationInputTokens > 0 {
+				fmt.Fprintf(w, " +%d", usage.CacheCreationInputTokens)
+			}
 
-					// Calculate and show cost if requested
-					if cfg.ShowCost {
-						modelName := ""
-						if model, ok := message["model"].(string); ok {
-							modelName = model
-						}
-						cost := calculateCost(usage, modelName)
-						if cost > 0 {
-							fmt.Printf(" $%.4f", cost)
-						}
+			// Calculate and show cost if requested
+			if cfg.ShowCost {
+				cost := calculateCost(*usage, message.Model)
+				if cost > 0 {
+					fmt.Fprintf(w, " $%.4f", cost)
+				}
+				// Show which price table entry was used
+				if cfg.Verbose {
+					if key := priceKeyForModel(message.Model); key != "" {
+						fmt.Fprintf(w, " @%s", key)
+					} else {
+						fmt.Fprintf(w, " (unpriced)")
 					}
-					fmt.Printf("]")
 				}
 			}
+			fmt.Fprintf(w, "]")
 		}
 
-		fmt.Println()
-		displayMessageContent(message, "  ")
-		fmt.Println()
+		fmt.Fprintln(w)
+		if thinkingOnly() {
+			for i := range message.Content {
+				if isThinkingBlock(&message.Content[i]) {
+					displayThinking(w, &message.Content[i], "  ")
+				}
+			}
+		} else {
+			displayMessageContent(w, message, "  ", tools)
+		}
+		fmt.Fprintln(w)
 	} else {
 		// Compact mode: fixed width role display, no metadata
-		fmt.Printf("%s[%s]%s %s%-9s%s - ",
+		fmt.Fprintf(w, "%s[%s]%s %s%-9s%s - ",
 			color(colorGray), timeStr, colorReset,
 			color(colorGreen+colorBold), "ASSISTANT", colorReset)
 
 		// Show brief summary in compact mode
 		summary := getMessageSummary(message)
 		if summary != "" {
-			fmt.Printf("%s\n", summary)
+			fmt.Fprintf(w, "%s\n", summary)
 		} else {
-			fmt.Printf("\n")
+			fmt.Fprintf(w, "\n")
 		}
+		displayInlineResultsCompact(w, message, tools)
 	}
 }
 
-// Display tool result from user message
-
-// Get tool name from tool result content
-func getToolNameFromResult(message map[string]interface{}, toolUseMap map[string]string) string {
-	contents := extractContent(message)
-	for _, content := range contents {
-		if content["type"] == "tool_result" {
-			if id, ok := content["tool_use_id"].(string); ok {
-				if name, exists := toolUseMap[id]; exists {
-					return name
-				}
-			}
-			break
-		}
+// Get the tool_use ID answered by the first tool result in a message
+func getToolUseIDFromResult(message *transcript.Message) string {
+	if result := message.Content.FirstOfType(transcript.BlockToolResult); result != nil {
+		return result.ToolUseID
 	}
 	return ""
 }
 
-// Get tool input for tool result
-func getToolInputForResult(message map[string]interface{}, toolInputMap map[string]map[string]interface{}) map[string]interface{} {
-	contents := extractContent(message)
-	for _, content := range contents {
-		if content["type"] == "tool_result" {
-			if id, ok := content["tool_use_id"].(string); ok {
-				if input, exists := toolInputMap[id]; exists {
-					return input
-				}
-			}
-			break
-		}
-	}
-	return nil
+// Get tool name from tool result content
+func getToolNameFromResult(message *transcript.Message, tools transcript.ToolIndex) string {
+	return tools.Name(getToolUseIDFromResult(message))
+}
+
+// Get the tool call answered by a tool result
+func getToolUseForResult(message *transcript.Message, tools transcript.ToolIndex) *transcript.ContentBlock {
+	return tools.ToolUse(getToolUseIDFromResult(message))
 }
 
 // Extract tool result info from contents
-func extractToolResult(contents []map[string]interface{}) (isError bool, resultContent string) {
-	for _, content := range contents {
-		if content["type"] == "tool_result" {
-			if err, ok := content["is_error"].(bool); ok {
-				isError = err
-			}
-			if contentStr, ok := content["content"].(string); ok {
-				resultContent = contentStr
-			}
-			return
-		}
+func extractToolResult(contents transcript.Content) (isError bool, resultContent string) {
+	if result := contents.FirstOfType(transcript.BlockToolResult); result != nil {
+		return result.IsError, result.Content.Text()
 	}
-	return
+	return false, ""
 }
 
 // Display error or OK status
-func displayCompactStatus(isError bool) {
+func displayCompactStatus(w io.Writer, isError bool) {
 	if isError {
-		fmt.Printf("[ERROR]")
+		fmt.Fprintf(w, "[ERROR]")
 	} else {
-		fmt.Printf("[OK]")
+		fmt.Fprintf(w, "[OK]")
 	}
 }
 
 // Display tool result in compact mode
-func displayToolResultCompact(message map[string]interface{}, toolName string, toolInput map[string]interface{}) {
-	contents := extractContent(message)
+func displayToolResultCompact(w io.Writer, message *transcript.Message, toolName string, tool *transcript.ContentBlock) {
+	contents := message.Content
 
 	// Route to specific handlers
 	switch {
-	case toolName == "TodoWrite" && toolInput != nil:
-		displayTodoWriteResultCompact(contents, toolInput)
-	case toolName == "Bash" && toolInput != nil:
-		displayBashResultCompact(contents, toolInput)
+	case toolName == "TodoWrite" && tool != nil:
+		displayTodoWriteResultCompact(w, contents, tool)
+	case toolName == "Bash" && tool != nil:
+		displayBashResultCompact(w, contents)
 	case isFileOperationTool(toolName):
-		displayFileToolResultCompact(contents, toolName, toolInput)
+		displayFileToolResultCompact(w, contents, toolName, tool)
 	case toolName == "WebFetch" || toolName == "WebSearch":
-		displayWebToolResultCompact(contents, toolName, toolInput)
+		displayWebToolResultCompact(w, contents, toolName)
 	case strings.HasPrefix(toolName, "mcp__"):
-		displayMCPToolResultCompact(contents, toolName, toolInput)
+		displayMCPToolResultCompact(w, contents, toolName)
 	default:
-		displayDefaultToolResultCompact(contents)
+		displayDefaultToolResultCompact(w, contents)
 	}
 }
 
@@ -411,33 +388,33 @@ func isFileOperationTool(toolName string) bool {
 }
 
 // Display default tool result in compact mode
-func displayDefaultToolResultCompact(contents []map[string]interface{}) {
+func displayDefaultToolResultCompact(w io.Writer, contents transcript.Content) {
 	isError, _ := extractToolResult(contents)
-	displayCompactStatus(isError)
-	fmt.Println()
+	displayCompactStatus(w, isError)
+	fmt.Fprintln(w)
 }
 
 // Display TodoWrite result in compact mode with special handling
-func displayTodoWriteResultCompact(contents []map[string]interface{}, toolInput map[string]interface{}) {
+func displayTodoWriteResultCompact(w io.Writer, contents transcript.Content, tool *transcript.ContentBlock) {
 	isError, _ := extractToolResult(contents)
-	displayCompactStatus(isError)
-	fmt.Printf(" ")
-	displayTodoWriteCompact(toolInput)
-	fmt.Println()
+	displayCompactStatus(w, isError)
+	fmt.Fprintf(w, " ")
+	displayTodoWriteCompact(w, tool)
+	fmt.Fprintln(w)
 }
 
 // Display Bash result in compact mode
-func displayBashResultCompact(contents []map[string]interface{}, toolInput map[string]interface{}) {
+func displayBashResultCompact(w io.Writer, contents transcript.Content) {
 	isError, resultContent := extractToolResult(contents)
 
 	// Try to extract exit code from the output
 	exitCode := extractExitCode(resultContent)
 
 	// Display status with exit code
-	displayCompactStatus(isError)
+	displayCompactStatus(w, isError)
 
 	if exitCode >= 0 {
-		fmt.Printf(" exit %d", exitCode)
+		fmt.Fprintf(w, " exit %d", exitCode)
 	}
 
 	// Display first line of output if available
@@ -446,12 +423,12 @@ func displayBashResultCompact(contents []map[string]interface{}, toolInput map[s
 		if len(lines) > 0 && lines[0] != "" {
 			firstLine := strings.TrimSpace(lines[0])
 			if firstLine != "" {
-				fmt.Printf(": %s", truncateRunes(firstLine, 50))
+				fmt.Fprintf(w, ": %s", truncateRunes(firstLine, 50))
 			}
 		}
 	}
 
-	fmt.Println()
+	fmt.Fprintln(w)
 }
 
 // Extract exit code from bash output (looks for common patterns)
@@ -476,54 +453,54 @@ func extractExitCode(output string) int {
 }
 
 // Display file operation tool results in compact mode
-func displayFileToolResultCompact(contents []map[string]interface{}, toolName string, toolInput map[string]interface{}) {
+func displayFileToolResultCompact(w io.Writer, contents transcript.Content, toolName string, tool *transcript.ContentBlock) {
 	isError, resultContent := extractToolResult(contents)
-	displayCompactStatus(isError)
+	displayCompactStatus(w, isError)
 
 	if !isError {
-		displayFileToolInfo(toolName, resultContent, toolInput)
+		displayFileToolInfo(w, toolName, resultContent, tool)
 	}
 
-	fmt.Println()
+	fmt.Fprintln(w)
 }
 
 // Display file tool specific info
-func displayFileToolInfo(toolName, resultContent string, toolInput map[string]interface{}) {
+func displayFileToolInfo(w io.Writer, toolName, resultContent string, tool *transcript.ContentBlock) {
 	switch toolName {
 	case "Read":
 		if resultContent != "" {
 			lines := strings.Split(resultContent, "\n")
-			fmt.Printf(" %d lines", len(lines))
+			fmt.Fprintf(w, " %d lines", len(lines))
 		}
 	case "Grep", "Glob":
-		displayCountInfo(toolName, resultContent)
+		displayCountInfo(w, toolName, resultContent)
 	case "Write":
-		fmt.Printf(" file created")
+		fmt.Fprintf(w, " file created")
 	case "Edit":
-		fmt.Printf(" file updated")
+		fmt.Fprintf(w, " file updated")
 	case "MultiEdit":
-		if edits, ok := toolInput["edits"].([]interface{}); ok {
-			fmt.Printf(" %d edits applied", len(edits))
+		if edits := tool.FileInput().Edits; edits != nil {
+			fmt.Fprintf(w, " %d edits applied", len(edits))
 		}
 	}
 }
 
 // Display count info for Grep and Glob
-func displayCountInfo(toolName, resultContent string) {
+func displayCountInfo(w io.Writer, toolName, resultContent string) {
 	lines := strings.Split(strings.TrimSpace(resultContent), "\n")
 	if lines[0] != "" {
 		if toolName == "Grep" {
-			fmt.Printf(" %d matches", len(lines))
+			fmt.Fprintf(w, " %d matches", len(lines))
 		} else {
-			fmt.Printf(" %d files found", len(lines))
+			fmt.Fprintf(w, " %d files found", len(lines))
 		}
 	}
 }
 
 // Display web tool results in compact mode
-func displayWebToolResultCompact(contents []map[string]interface{}, toolName string, toolInput map[string]interface{}) {
+func displayWebToolResultCompact(w io.Writer, contents transcript.Content, toolName string) {
 	isError, resultContent := extractToolResult(contents)
-	displayCompactStatus(isError)
+	displayCompactStatus(w, isError)
 
 	// Display tool-specific info
 	switch toolName {
@@ -534,7 +511,7 @@ func displayWebToolResultCompact(contents []map[string]interface{}, toolName str
 			for _, line := range lines {
 				line = strings.TrimSpace(line)
 				if line != "" {
-					fmt.Printf(" %s", truncateRunes(line, 50))
+					fmt.Fprintf(w, " %s", truncateRunes(line, 50))
 					break
 				}
 			}
@@ -544,28 +521,28 @@ func displayWebToolResultCompact(contents []map[string]interface{}, toolName str
 			// Count search results
 			resultCount := strings.Count(resultContent, "<search_result>")
 			if resultCount > 0 {
-				fmt.Printf(" %d results", resultCount)
+				fmt.Fprintf(w, " %d results", resultCount)
 			}
 		}
 	}
 
-	fmt.Println()
+	fmt.Fprintln(w)
 }
 
 // Display MCP tool results in compact mode
-func displayMCPToolResultCompact(contents []map[string]interface{}, toolName string, toolInput map[string]interface{}) {
+func displayMCPToolResultCompact(w io.Writer, contents transcript.Content, toolName string) {
 	isError, resultContent := extractToolResult(contents)
-	displayCompactStatus(isError)
+	displayCompactStatus(w, isError)
 
 	if !isError && resultContent != "" {
-		displayMCPToolInfo(toolName, resultContent)
+		displayMCPToolInfo(w, toolName, resultContent)
 	}
 
-	fmt.Println()
+	fmt.Fprintln(w)
 }
 
 // Display MCP tool specific info
-func displayMCPToolInfo(toolName, resultContent string) {
+func displayMCPToolInfo(w io.Writer, toolName, resultContent string) {
 	parts := strings.Split(toolName, "__")
 	if len(parts) <= 1 {
 		return
@@ -575,36 +552,36 @@ func displayMCPToolInfo(toolName, resultContent string) {
 
 	switch {
 	case strings.HasPrefix(action, "create_"):
-		displayMCPCreateInfo(resultContent)
+		displayMCPCreateInfo(w, resultContent)
 	case strings.HasPrefix(action, "list_"):
-		displayMCPListInfo(resultContent)
+		displayMCPListInfo(w, resultContent)
 	case strings.HasPrefix(action, "get_"):
-		displayMCPGetInfo(resultContent)
+		displayMCPGetInfo(w, resultContent)
 	}
 }
 
 // Display info for MCP create actions
-func displayMCPCreateInfo(resultContent string) {
+func displayMCPCreateInfo(w io.Writer, resultContent string) {
 	if match := extractJSONValue(resultContent, "id"); match != "" {
-		fmt.Printf(" Created: %s", match)
+		fmt.Fprintf(w, " Created: %s", match)
 	} else if match := extractJSONValue(resultContent, "title"); match != "" {
-		fmt.Printf(" Created: %s", truncateRunes(match, 30))
+		fmt.Fprintf(w, " Created: %s", truncateRunes(match, 30))
 	}
 }
 
 // Display info for MCP list actions
-func displayMCPListInfo(resultContent string) {
+func displayMCPListInfo(w io.Writer, resultContent string) {
 	if count := countJSONArrayItems(resultContent); count > 0 {
-		fmt.Printf(" Found %d items", count)
+		fmt.Fprintf(w, " Found %d items", count)
 	}
 }
 
 // Display info for MCP get actions
-func displayMCPGetInfo(resultContent string) {
+func displayMCPGetInfo(w io.Writer, resultContent string) {
 	if match := extractJSONValue(resultContent, "title"); match != "" {
-		fmt.Printf(" %s", truncateRunes(match, 40))
+		fmt.Fprintf(w, " %s", truncateRunes(match, 40))
 	} else if match := extractJSONValue(resultContent, "name"); match != "" {
-		fmt.Printf(" %s", truncateRunes(match, 40))
+		fmt.Fprintf(w, " %s", truncateRunes(match, 40))
 	}
 }
 
@@ -643,112 +620,125 @@ func countJSONArrayItems(content string) int {
 }
 
 // Display TodoWrite in compact mode
-func displayTodoWriteCompact(toolInput map[string]interface{}) {
-	if todos, ok := toolInput["todos"].([]interface{}); ok && len(todos) > 0 {
-		// Find the in_progress or most recently changed todo
-		var focusedTodo map[string]interface{}
-		for _, todoItem := range todos {
-			if todo, ok := todoItem.(map[string]interface{}); ok {
-				if status, ok := todo["status"].(string); ok && status == "in_progress" {
-					focusedTodo = todo
-					break
-				}
-				// If no in_progress, use the first todo
-				if focusedTodo == nil {
-					focusedTodo = todo
-				}
-			}
-		}
-		if focusedTodo != nil {
-			if content, ok := focusedTodo["content"].(string); ok {
-				status, _ := focusedTodo["status"].(string)
-				statusIcon, statusColor := getTodoStatusIcon(status)
-				fmt.Printf("%s%s%s %s", color(statusColor), statusIcon, colorReset, truncateRunes(content, 50))
-			}
+func displayTodoWriteCompact(w io.Writer, tool *transcript.ContentBlock) {
+	todos := tool.Todos()
+	if len(todos) == 0 {
+		return
+	}
+
+	// Find the in_progress todo, falling back to the first one
+	focusedTodo := todos[0]
+	for _, todo := range todos {
+		if todo.Status == "in_progress" {
+			focusedTodo = todo
+			break
 		}
 	}
+	if focusedTodo.Content != "" {
+		statusIcon, statusColor := getTodoStatusIcon(focusedTodo.Status)
+		fmt.Fprintf(w, "%s%s%s %s", color(statusColor), statusIcon, colorReset, truncateRunes(focusedTodo.Content, 50))
+	}
 }
 
 // Display tool result from user message (simplified version)
-func displayToolResultSimple(message map[string]interface{}, timeStr, versionStr string, toolUseMap map[string]string, toolInputMap map[string]map[string]interface{}, toolUseResult map[string]interface{}) {
-	// Get tool name and input
-	toolName := getToolNameFromResult(message, toolUseMap)
-	toolInput := getToolInputForResult(message, toolInputMap)
+func displayToolResultSimple(w io.Writer, entry *transcript.Entry, timeStr, versionStr string, tools transcript.ToolIndex, compact bool) {
+	message := entry.Message
+
+	// Get tool name and call
+	toolName := getToolNameFromResult(message, tools)
+	tool := getToolUseForResult(message, tools)
 
 	// Display header
-	if !cfg.Compact {
-		fmt.Printf("%s[%s]%s %sTOOL%s",
+	if !compact {
+		fmt.Fprintf(w, "%s[%s]%s %sTOOL%s",
 			color(colorGray), timeStr, versionStr,
 			color(colorCyan+colorBold), colorReset)
 		if toolName != "" {
-			fmt.Printf(" %s(%s)%s", color(colorGray), toolName, colorReset)
+			fmt.Fprintf(w, " %s(%s)%s", color(colorGray), toolName, colorReset)
 		}
-		fmt.Println()
-		displayMessageContentFull(message, "  ", toolName, toolUseResult, toolInput)
-		fmt.Println()
+		fmt.Fprintln(w)
+		displayMessageContentFull(w, message, "  ", toolName, entry.ToolUseResultMap(), tool, tools)
+		fmt.Fprintln(w)
 		return
 	}
 
 	// Compact mode
-	fmt.Printf("%s[%s]%s %s%-9s%s - ",
+	fmt.Fprintf(w, "%s[%s]%s %s%-9s%s - ",
 		color(colorGray), timeStr, colorReset,
 		color(colorCyan+colorBold), "TOOL", colorReset)
-	displayToolResultCompact(message, toolName, toolInput)
+	displayToolResultCompact(w, message, toolName, tool)
 }
 
 // Display message content
-func displayMessageContent(message map[string]interface{}, indent string) {
-	displayMessageContentFull(message, indent, "", nil, nil)
+func displayMessageContent(w io.Writer, message *transcript.Message, indent string, tools transcript.ToolIndex) {
+	displayMessageContentFull(w, message, indent, "", nil, nil, tools)
 }
 
 // Display message content with full context
-func displayMessageContentFull(message map[string]interface{}, indent, toolName string, toolUseResult, toolInput map[string]interface{}) {
-	content := extractContent(message)
-
-	for _, item := range content {
-		switch item["type"] {
-		case "text":
-			if text, ok := item["text"].(string); ok {
-				displayText(text, indent)
+func displayMessageContentFull(w io.Writer, message *transcript.Message, indent, toolName string, toolUseResult map[string]interface{}, tool *transcript.ContentBlock, tools transcript.ToolIndex) {
+	for i := range message.Content {
+		item := &message.Content[i]
+		switch item.Type {
+		case transcript.BlockText:
+			displayText(w, item.Text, indent)
+		case transcript.BlockToolUse:
+			displayToolUse(w, item, indent, tools)
+			displayInlineResult(w, item, indent+"  ", tools)
+		case transcript.BlockToolResult:
+			displayToolResultFull(w, item, indent, toolName, toolUseResult, tool)
+		case transcript.BlockThinking, transcript.BlockRedactedThinking:
+			if !logConfig.noThinking {
+				displayThinking(w, item, indent)
 			}
-		case "tool_use":
-			displayToolUse(item, indent)
-		case "tool_result":
-			displayToolResultFull(item, indent, toolName, toolUseResult, toolInput)
 		}
 	}
 }
 
 // Display text content
-func displayText(text, indent string) {
+func displayText(w io.Writer, text, indent string) {
 	lines := strings.Split(text, "\n")
 	for _, line := range lines {
-		fmt.Printf("%s%s\n", indent, line)
+		fmt.Fprintf(w, "%s%s\n", indent, highlightMatches(line))
 	}
 }
 
 // Display text content with line limit
-func displayTextTruncated(text, indent string, maxLines int) {
+func displayTextTruncated(w io.Writer, text, indent string, maxLines int) {
 	lines := strings.Split(text, "\n")
 	totalLines := len(lines)
 
 	// Show all lines if within limit
 	if totalLines <= maxLines+2 { // +2 for better UX (don't truncate if we're close)
 		for _, line := range lines {
-			fmt.Printf("%s%s\n", indent, line)
+			fmt.Fprintf(w, "%s%s\n", indent, highlightMatches(line))
 		}
 		return
 	}
 
 	// Show first maxLines lines
 	for i := 0; i < maxLines && i < totalLines; i++ {
-		fmt.Printf("%s%s\n", indent, lines[i])
+		fmt.Fprintf(w, "%s%s\n", indent, highlightMatches(lines[i]))
+	}
+
+	// Show --grep matches among the hidden lines, between truncation notices
+	skipped := 0
+	for _, line := range lines[maxLines:] {
+		if logConfig.grep == nil || !logConfig.grep.MatchString(line) {
+			skipped++
+			continue
+		}
+		if skipped > 0 {
+			fmt.Fprintf(w, "%s%s... (%d lines)%s\n", indent, color(colorGray), skipped, color(colorReset))
+			skipped = 0
+		}
+		fmt.Fprintf(w, "%s%s\n", indent, highlightMatches(line))
 	}
 
 	// Show truncation notice
-	remaining := totalLines - maxLines
-	fmt.Printf("%s%s... (%d more lines)%s\n",
-		indent, color(colorGray), remaining, colorReset)
+	if skipped > 0 {
+		fmt.Fprintf(w, "%s%s... (%d more lines)%s\n",
+			indent, color(colorGray), skipped, colorReset)
+	}
 }
 
 // Truncate string by rune count (for proper UTF-8 handling)
@@ -766,61 +756,64 @@ func truncateRunes(s string, maxRunes int) string {
 }
 
 // Display tool use
-func displayToolUse(tool map[string]interface{}, indent string) {
-	fmt.Printf("%s%s[Tool Use]%s", indent, color(colorYellow), colorReset)
+func displayToolUse(w io.Writer, tool *transcript.ContentBlock, indent string, tools transcript.ToolIndex) {
+	fmt.Fprintf(w, "%s%s[Tool Use]%s", indent, color(colorYellow), colorReset)
 
-	if name, ok := tool["name"].(string); ok {
-		fmt.Printf(" %s", name)
+	if tool.Name != "" {
+		fmt.Fprintf(w, " %s", tool.Name)
 		// Add MCP label for MCP tools
-		if strings.HasPrefix(name, "mcp__") {
-			fmt.Printf(" %s(MCP)%s", color(colorCyan), colorReset)
+		if strings.HasPrefix(tool.Name, "mcp__") {
+			fmt.Fprintf(w, " %s(MCP)%s", color(colorCyan), colorReset)
 		}
 	}
 
-	if id, ok := tool["id"].(string); ok {
-		fmt.Printf(" %s(ID: %s)%s", color(colorGray), id, colorReset)
+	if tool.ID != "" {
+		fmt.Fprintf(w, " %s(ID: %s)%s", color(colorGray), tool.ID, colorReset)
 	}
 
-	fmt.Println()
+	fmt.Fprintln(w)
 
-	// Display input as key: value format for all tools
-	if input, ok := tool["input"].(map[string]interface{}); ok && len(input) > 0 {
-		displayToolInputAsKeyValue(input, indent+"  ")
+	// File changes are shown as a diff, other input as key: value
+	if isDiffTool(tool.Name) && displayToolDiff(w, tool, indent+"  ", tools) {
+		return
+	}
+	if input := tool.InputFields(); len(input) > 0 {
+		displayToolInputAsKeyValue(w, input, indent+"  ")
 	}
 }
 
 // Display tool input as key: value format with appropriate formatting
-func displayToolInputAsKeyValue(input map[string]interface{}, indent string) {
+func displayToolInputAsKeyValue(w io.Writer, input map[string]interface{}, indent string) {
 	for key, value := range input {
-		fmt.Printf("%s%s%s:%s ", indent, color(colorGray), key, colorReset)
-		displayToolInputValue(key, value)
+		fmt.Fprintf(w, "%s%s%s:%s ", indent, color(colorGray), key, colorReset)
+		displayToolInputValue(w, key, value)
 	}
 }
 
 // Display individual tool input value based on type
-func displayToolInputValue(key string, value interface{}) {
+func displayToolInputValue(w io.Writer, key string, value interface{}) {
 	switch v := value.(type) {
 	case string:
 		// Path keys get special treatment
 		if isPathKey(key) {
-			fmt.Printf("%s\n", v)
+			fmt.Fprintf(w, "%s\n", v)
 		} else {
-			fmt.Printf("%s\n", formatStringValue(v, 100))
+			fmt.Fprintf(w, "%s\n", formatStringValue(v, 100))
 		}
 	case []interface{}:
-		fmt.Printf("[%d items]\n", len(v))
+		fmt.Fprintf(w, "[%d items]\n", len(v))
 	case map[string]interface{}:
-		fmt.Printf("{%d keys}\n", len(v))
+		fmt.Fprintf(w, "{%d keys}\n", len(v))
 	case bool, float64, int:
-		fmt.Printf("%v\n", v)
+		fmt.Fprintf(w, "%v\n", v)
 	case nil:
-		fmt.Printf("null\n")
+		fmt.Fprintf(w, "null\n")
 	default:
 		// JSON fallback for complex types
 		if data, err := json.Marshal(value); err == nil {
-			fmt.Printf("%s\n", formatStringValue(string(data), 100))
+			fmt.Fprintf(w, "%s\n", formatStringValue(string(data), 100))
 		} else {
-			fmt.Printf("%v\n", value)
+			fmt.Fprintf(w, "%v\n", value)
 		}
 	}
 }
@@ -847,40 +840,30 @@ func isPathKey(key string) bool {
 }
 
 // Display tool result content with full context
-func displayToolResultFull(result map[string]interface{}, indent, toolName string, toolUseResult, toolInput map[string]interface{}) {
+func displayToolResultFull(w io.Writer, result *transcript.ContentBlock, indent, toolName string, toolUseResult map[string]interface{}, tool *transcript.ContentBlock) {
 	// Check if it's an error
-	if isError, ok := result["is_error"].(bool); ok && isError {
-		fmt.Printf("%s%s[ERROR]%s\n", indent, color(colorRed), colorReset)
+	if result.IsError {
+		fmt.Fprintf(w, "%s%s[ERROR]%s\n", indent, color(colorRed), colorReset)
 	}
 
 	// Special handling for TodoWrite
 	if toolName == "TodoWrite" && toolUseResult != nil {
-		displayTodoWriteResultWithData(result, indent, toolUseResult)
+		displayTodoWriteResultWithData(w, result, indent, toolUseResult)
 		return
 	}
 
-	// Display content - handle both string and array types
+	// Display content - string content is decoded as a single text block
 	hasContent := false
-	switch content := result["content"].(type) {
-	case string:
-		if content != "" {
-			displayTextTruncated(content, indent, 10)
+	for _, item := range result.Content {
+		if item.Type == transcript.BlockText && item.Text != "" {
+			displayTextTruncated(w, item.Text, indent, 10)
 			hasContent = true
 		}
-	case []interface{}:
-		for _, item := range content {
-			if m, ok := item.(map[string]interface{}); ok && m["type"] == "text" {
-				if text, ok := m["text"].(string); ok && text != "" {
-					displayTextTruncated(text, indent, 10)
-					hasContent = true
-				}
-			}
-		}
 	}
 
 	// Show "(No content)" if no content was displayed
 	if !hasContent {
-		fmt.Printf("%s%s(No content)%s\n", indent, color(colorGray), colorReset)
+		fmt.Fprintf(w, "%s%s(No content)%s\n", indent, color(colorGray), colorReset)
 	}
 }
 
@@ -899,45 +882,39 @@ func getTodoStatusIcon(status string) (icon, color string) {
 }
 
 // Display a single todo item
-func displayTodoItem(todo map[string]interface{}, indent string) {
-	content, _ := todo["content"].(string)
-	status, _ := todo["status"].(string)
-	priority, _ := todo["priority"].(string)
-
-	statusIcon, statusColor := getTodoStatusIcon(status)
+func displayTodoItem(w io.Writer, todo transcript.Todo, indent string) {
+	statusIcon, statusColor := getTodoStatusIcon(todo.Status)
 
 	// Display the todo item
-	fmt.Printf("%s%s%s%s %s", indent, color(statusColor), statusIcon, colorReset, content)
+	fmt.Fprintf(w, "%s%s%s%s %s", indent, color(statusColor), statusIcon, colorReset, todo.Content)
 
 	// Add priority indicator
-	switch priority {
+	switch todo.Priority {
 	case "high":
-		fmt.Printf(" %s[HIGH]%s", color(colorRed), colorReset)
+		fmt.Fprintf(w, " %s[HIGH]%s", color(colorRed), colorReset)
 	case "medium":
-		fmt.Printf(" %s[MED]%s", color(colorYellow), colorReset)
+		fmt.Fprintf(w, " %s[MED]%s", color(colorYellow), colorReset)
 	}
 
-	fmt.Println()
+	fmt.Fprintln(w)
 }
 
 // Display TodoWrite result with structured data
-func displayTodoWriteResultWithData(result map[string]interface{}, indent string, toolUseResult map[string]interface{}) {
+func displayTodoWriteResultWithData(w io.Writer, result *transcript.ContentBlock, indent string, toolUseResult map[string]interface{}) {
 	// Check for newTodos in the result
-	if newTodos, ok := toolUseResult["newTodos"].([]interface{}); ok {
+	if newTodos, ok := transcript.TodosFromResult(toolUseResult); ok {
 		// Display each todo item
-		for _, todoItem := range newTodos {
-			if todo, ok := todoItem.(map[string]interface{}); ok {
-				displayTodoItem(todo, indent)
-			}
+		for _, todo := range newTodos {
+			displayTodoItem(w, todo, indent)
 		}
 
 		// Changes are no longer shown since verbose mode is removed
 	} else {
 		// Fallback to content display if no structured data
-		if content, ok := result["content"].(string); ok && content != "" {
+		if content := result.Content.Text(); content != "" {
 			// Suppress the default message
 			if !strings.Contains(content, "Todos have been modified successfully") {
-				displayText(content, indent)
+				displayText(w, content, indent)
 			}
 		}
 	}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"strings"
 	"testing"
+
+	"github.com/Sixeight/ccl/transcript"
 )
 
 // Test basic display functionality without worrying about exact formatting
@@ -16,39 +20,11 @@ func TestDisplayFunctionality(t *testing.T) {
 	// Disable colors for cleaner test output
 	cfg.NoColor = true
 
-	t.Run("extractContent handles different formats", func(t *testing.T) {
-		// Test string content
-		msg1 := map[string]interface{}{
-			"content": "Simple string",
-		}
-		content1 := extractContent(msg1)
-		if len(content1) != 1 {
-			t.Errorf("Expected 1 content item for string content, got %d", len(content1))
-		}
-
-		// Test array content
-		msg2 := map[string]interface{}{
-			"content": []interface{}{
-				map[string]interface{}{
-					"type": "text",
-					"text": "Hello",
-				},
-			},
-		}
-		content2 := extractContent(msg2)
-		if len(content2) != 1 {
-			t.Errorf("Expected 1 content item for array content, got %d", len(content2))
-		}
-	})
-
 	t.Run("getMessageSummary creates proper summaries", func(t *testing.T) {
 		// Test text summary
-		msg1 := map[string]interface{}{
-			"content": []interface{}{
-				map[string]interface{}{
-					"type": "text",
-					"text": "This is a test message",
-				},
+		msg1 := &transcript.Message{
+			Content: transcript.Content{
+				{Type: transcript.BlockText, Text: "This is a test message"},
 			},
 		}
 		summary1 := getMessageSummary(msg1)
@@ -57,14 +33,12 @@ func TestDisplayFunctionality(t *testing.T) {
 		}
 
 		// Test tool use summary
-		msg2 := map[string]interface{}{
-			"content": []interface{}{
-				map[string]interface{}{
-					"type": "tool_use",
-					"name": "Bash",
-					"input": map[string]interface{}{
-						"command": "ls -la",
-					},
+		msg2 := &transcript.Message{
+			Content: transcript.Content{
+				{
+					Type:  transcript.BlockToolUse,
+					Name:  "Bash",
+					Input: json.RawMessage(`{"command":"ls -la"}`),
 				},
 			},
 		}
@@ -106,14 +80,12 @@ func TestDisplayFunctionality(t *testing.T) {
 		}()
 
 		// Test file_path in non-Bash tools
-		msg := map[string]interface{}{
-			"content": []interface{}{
-				map[string]interface{}{
-					"type": "tool_use",
-					"name": "Read",
-					"input": map[string]interface{}{
-						"file_path": "/path/to/file.go",
-					},
+		msg := &transcript.Message{
+			Content: transcript.Content{
+				{
+					Type:  transcript.BlockToolUse,
+					Name:  "Read",
+					Input: json.RawMessage(`{"file_path":"/path/to/file.go"}`),
 				},
 			},
 		}
@@ -126,15 +98,12 @@ func TestDisplayFunctionality(t *testing.T) {
 		}
 
 		// Test that Write tool also shows file_path
-		msg2 := map[string]interface{}{
-			"content": []interface{}{
-				map[string]interface{}{
-					"type": "tool_use",
-					"name": "Write",
-					"input": map[string]interface{}{
-						"file_path": "/another/path/file.txt",
-						"content":   "file content here",
-					},
+		msg2 := &transcript.Message{
+			Content: transcript.Content{
+				{
+					Type:  transcript.BlockToolUse,
+					Name:  "Write",
+					Input: json.RawMessage(`{"file_path":"/another/path/file.txt","content":"file content here"}`),
 				},
 			},
 		}
@@ -143,4 +112,29 @@ func TestDisplayFunctionality(t *testing.T) {
 			t.Errorf("Expected file path in summary for Write tool, got: %s", summary2)
 		}
 	})
+
+	t.Run("displayEntryWithOptions writes to the given writer", func(t *testing.T) {
+		origOutput := output
+		defer func() {
+			output = origOutput
+		}()
+		var global, buf bytes.Buffer
+		output = &global
+
+		entry := &transcript.Entry{
+			Type: "user",
+			Message: &transcript.Message{
+				Role:    "user",
+				Content: transcript.Content{{Type: transcript.BlockText, Text: "hello writer"}},
+			},
+		}
+		displayEntryWithOptions(entry, nil, displayOptions{w: &buf})
+
+		if !strings.Contains(buf.String(), "hello writer") {
+			t.Errorf("Expected entry in the given writer, got: %q", buf.String())
+		}
+		if global.Len() != 0 {
+			t.Errorf("Expected nothing written to output, got: %q", global.String())
+		}
+	})
 }
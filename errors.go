@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+// toolError is a tool result reported as an error, with the call that caused it
+type toolError struct {
+	Name        string
+	Call        *transcript.ContentBlock // nil when the call is not in the transcript
+	CallEntry   *transcript.Entry
+	Result      *transcript.ContentBlock
+	ResultEntry *transcript.Entry
+}
+
+// collectToolErrors pairs every error result among the displayed entries with its call
+func collectToolErrors(entries []*transcript.Entry, tools transcript.ToolIndex) []toolError {
+	var errs []toolError
+	for _, entry := range entries {
+		if entry.Message == nil || !shouldDisplayEntryWithToolInfo(entry.Type, entry, tools) {
+			continue
+		}
+		for i := range entry.Message.Content {
+			block := &entry.Message.Content[i]
+			if block.Type != transcript.BlockToolResult || !block.IsError {
+				continue
+			}
+			toolErr := toolError{Name: "unknown", Result: block, ResultEntry: entry}
+			if call := tools[block.ToolUseID]; call != nil {
+				toolErr.Name, toolErr.Call, toolErr.CallEntry = call.Block.Name, call.Block, call.Entry
+			}
+			errs = append(errs, toolErr)
+		}
+	}
+	return errs
+}
+
+// countToolCalls counts the tool calls among entries by tool name
+func countToolCalls(entries []*transcript.Entry) map[string]int {
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		if entry.Type != "assistant" || entry.Message == nil {
+			continue
+		}
+		for _, block := range entry.Message.Content {
+			if block.Type == transcript.BlockToolUse {
+				counts[block.Name]++
+			}
+		}
+	}
+	return counts
+}
+
+// displayToolErrors prints each failed call together with its error,
+// followed by the number of errors per tool
+func displayToolErrors(entries []*transcript.Entry, tools transcript.ToolIndex) {
+	errs := collectToolErrors(entries, tools)
+	calls := countToolCalls(entries)
+	counts := make(map[string]int)
+	for _, toolErr := range errs {
+		counts[toolErr.Name]++
+	}
+
+	if cfg.OutputFormat == "json" {
+		displayToolErrorsAsJSON(errs, counts)
+		return
+	}
+
+	for _, toolErr := range errs {
+		displayToolError(toolErr, tools)
+	}
+
+	if len(errs) == 0 {
+		fmt.Fprintf(output, "No tool errors\n")
+		return
+	}
+	fmt.Fprintf(output, "%sErrors by tool%s\n", color(colorBold), color(colorReset))
+	for _, name := range sortedToolNames(counts) {
+		fmt.Fprintf(output, "  %s%-14s%s %6s", color(colorCyan), name, color(colorReset), formatNumber(counts[name]))
+		if calls[name] > 0 {
+			fmt.Fprintf(output, " %sof %s call%s%s", color(colorGray), formatNumber(calls[name]), pluralize(calls[name]), color(colorReset))
+		}
+		fmt.Fprintln(output)
+	}
+	total := 0
+	for _, count := range calls {
+		total += count
+	}
+	fmt.Fprintf(output, "%d error%s in %d tool call%s\n", len(errs), pluralize(len(errs)), total, pluralize(total))
+}
+
+// displayToolError prints a failed call followed by its error result
+func displayToolError(toolErr toolError, tools transcript.ToolIndex) {
+	fmt.Fprintf(output, "%s[%s]%s %s%sERROR%s %s",
+		color(colorGray), formatTimestamp(toolErr.ResultEntry.Timestamp), formatVersionInfo(toolErr.ResultEntry.Version, cfg.Compact),
+		color(colorRed), color(colorBold), color(colorReset), toolErr.Name)
+	if latency, ok := toolLatency(toolErr.CallEntry, toolErr.ResultEntry); ok {
+		fmt.Fprintf(output, " %s(after %s)%s", color(colorGray), formatElapsed(latency), color(colorReset))
+	}
+	fmt.Fprintln(output)
+
+	if toolErr.Call != nil {
+		displayToolUse(output, toolErr.Call, "  ", tools)
+	} else {
+		fmt.Fprintf(output, "  %s(call %s not found)%s\n", color(colorGray), toolErr.Result.ToolUseID, color(colorReset))
+	}
+	displayToolResultFull(output, toolErr.Result, "  ", toolErr.Name, toolErr.ResultEntry.ToolUseResultMap(), toolErr.Call)
+	fmt.Fprintln(output)
+}
+
+// displayToolErrorsAsJSON prints the failed calls and counts as one JSON object
+func displayToolErrorsAsJSON(errs []toolError, counts map[string]int) {
+	type jsonToolError struct {
+		Timestamp string          `json:"timestamp,omitempty"`
+		Tool      string          `json:"tool"`
+		ToolUseID string          `json:"tool_use_id"`
+		Input     json.RawMessage `json:"input,omitempty"`
+		Error     string          `json:"error"`
+	}
+	report := struct {
+		Errors []jsonToolError `json:"errors"`
+		Counts map[string]int  `json:"counts"`
+	}{Errors: []jsonToolError{}, Counts: counts}
+
+	for _, toolErr := range errs {
+		e := jsonToolError{
+			Timestamp: toolErr.ResultEntry.Timestamp,
+			Tool:      toolErr.Name,
+			ToolUseID: toolErr.Result.ToolUseID,
+			Error:     toolErr.Result.Content.Text(),
+		}
+		if toolErr.Call != nil {
+			e.Input = toolErr.Call.Input
+		}
+		report.Errors = append(report.Errors, e)
+	}
+	jsonData, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Fprintln(output, string(jsonData))
+}
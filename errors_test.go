@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+func TestDisplayToolErrors(t *testing.T) {
+	origCfg, origOutput := cfg, output
+	defer func() {
+		cfg, output = origCfg, origOutput
+	}()
+	cfg = Config{NoColor: true}
+
+	input := strings.Join([]string{
+		`{"type":"assistant","uuid":"a1","timestamp":"2025-06-22T09:00:00Z","message":{"content":[{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"go build"}},{"type":"tool_use","id":"t2","name":"Bash","input":{"command":"go vet"}}]}}`,
+		`{"type":"user","uuid":"u1","timestamp":"2025-06-22T09:00:03Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"exit status 1","is_error":true}]}}`,
+		`{"type":"user","uuid":"u2","timestamp":"2025-06-22T09:00:04Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t2","content":"ok"}]}}`,
+		`{"type":"assistant","uuid":"a2","timestamp":"2025-06-22T09:00:10Z","message":{"content":[{"type":"tool_use","id":"t3","name":"Read","input":{"file_path":"/etc/shadow"}}]}}`,
+		`{"type":"user","uuid":"u3","timestamp":"2025-06-22T09:00:10.250Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t3","content":"Permission denied","is_error":true}]}}`,
+		`{"type":"user","uuid":"u4","timestamp":"2025-06-22T09:00:20Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"gone","content":"stale","is_error":true}]}}`,
+	}, "\n")
+	entries, err := transcript.ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	tools := transcript.NewToolIndex(entries)
+
+	var buf bytes.Buffer
+	output = &buf
+	displayToolErrors(entries, tools)
+	got := buf.String()
+
+	for _, want := range []string{
+		"ERROR Bash (after 3.0s)\n",
+		" go build\n",
+		"  exit status 1\n",
+		"ERROR Read (after 250ms)\n",
+		"  Permission denied\n",
+		"ERROR unknown\n  (call gone not found)\n",
+		"Errors by tool\n",
+		"  Bash                1 of 2 calls\n",
+		"  Read                1 of 1 call\n",
+		"  unknown             1\n",
+		"3 errors in 3 tool calls\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "go vet") {
+		t.Errorf("output contains a successful call:\n%s", got)
+	}
+}
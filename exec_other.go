@@ -0,0 +1,15 @@
+//go:build !linux && !darwin
+
+package main
+
+import "os/exec"
+
+// shellCommand runs a command line through the shell
+func shellCommand(command string) *exec.Cmd {
+	return exec.Command("cmd", "/C", command)
+}
+
+// stopCommand stops a command started by shellCommand
+func stopCommand(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
@@ -0,0 +1,21 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// shellCommand runs a command line through the shell, in a process group
+// of its own so that stopping it also stops what it started
+func shellCommand(command string) *exec.Cmd {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return cmd
+}
+
+// stopCommand stops a command started by shellCommand
+func stopCommand(cmd *exec.Cmd) {
+	_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+}
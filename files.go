@@ -0,0 +1,397 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+// File status after replaying a session
+const (
+	fileComplete = "complete" // Content known from a full baseline
+	filePartial  = "partial"  // Edited without a Read or Write before
+	fileConflict = "conflict" // An edit did not match the replayed content
+)
+
+// fileState is the replayed state of one file changed by a session
+type fileState struct {
+	Path      string `json:"path"`
+	Reads     int    `json:"reads"`
+	Edits     int    `json:"edits"` // Edit and MultiEdit calls
+	Writes    int    `json:"writes"`
+	Failed    int    `json:"failed"` // Calls whose result was an error
+	Conflicts int    `json:"conflicts"`
+	Status    string `json:"status"`
+
+	content string
+	known   bool   // content holds the whole file
+	drift   string // filePartial or fileConflict once an edit could not be replayed
+
+	// Content before the first change made while recording
+	base      string
+	baseKnown bool
+	baseSet   bool
+	created   bool // The first recorded change created the file
+
+	// Structured patches of the recorded changes, for a file whose base
+	// is unknown; unpatched counts the changes recorded without one
+	patches   [][]diffHunk
+	unpatched int
+}
+
+// fileReplay replays the file operations of a session in order
+type fileReplay struct {
+	files     map[string]*fileState
+	order     []string // Paths in order of first use
+	recording bool     // Changes set the base of files they touch first
+}
+
+// newFileReplay creates an empty replay that records from the start
+func newFileReplay() *fileReplay {
+	return &fileReplay{files: make(map[string]*fileState), recording: true}
+}
+
+// replayFiles replays every Read, Write, Edit and MultiEdit call of a session.
+// All entries are replayed, including abandoned branches, since their
+// changes were made on disk all the same.
+func replayFiles(entries []*transcript.Entry) *fileReplay {
+	tools := transcript.NewToolIndex(entries)
+	replay := newFileReplay()
+	for _, entry := range entries {
+		replay.apply(entry, tools)
+	}
+	return replay
+}
+
+// file returns the state of a path, creating it on first use
+func (r *fileReplay) file(path string) *fileState {
+	state, ok := r.files[path]
+	if !ok {
+		state = &fileState{Path: path}
+		r.files[path] = state
+		r.order = append(r.order, path)
+	}
+	return state
+}
+
+// changed returns the states of files that were written or edited
+func (r *fileReplay) changed() []*fileState {
+	var files []*fileState
+	for _, path := range r.order {
+		if state := r.files[path]; state.Edits > 0 || state.Writes > 0 {
+			files = append(files, state)
+		}
+	}
+	return files
+}
+
+// apply replays the tool calls of an assistant entry. Calls without a
+// result never ran, and calls with an error result changed nothing.
+func (r *fileReplay) apply(entry *transcript.Entry, tools transcript.ToolIndex) {
+	if entry.Type != "assistant" || entry.Message == nil {
+		return
+	}
+	for i := range entry.Message.Content {
+		block := &entry.Message.Content[i]
+		if block.Type != transcript.BlockToolUse {
+			continue
+		}
+		switch block.Name {
+		case "Read", "Write", "Edit", "MultiEdit":
+		default:
+			continue
+		}
+		path := block.FileInput().FilePath
+		if path == "" {
+			continue
+		}
+		// Sessions recorded on Unix keep their paths when read elsewhere
+		if !filepath.IsAbs(path) && !strings.HasPrefix(path, "/") && entry.Cwd != "" {
+			path = filepath.Join(entry.Cwd, path)
+		}
+
+		result, resultBlock := tools.Result(block.ID)
+		if result == nil {
+			continue
+		}
+		state := r.file(path)
+		if resultBlock.IsError {
+			if block.Name != "Read" {
+				state.Failed++
+			}
+			continue
+		}
+		state.replay(block, result, r.recording)
+	}
+}
+
+// replay applies one successful tool call to the file state;
+// recording sets the base before the first change
+func (s *fileState) replay(tool *transcript.ContentBlock, result *transcript.Entry, recording bool) {
+	toolUseResult := result.ToolUseResultMap()
+	input := tool.FileInput()
+
+	switch tool.Name {
+	case "Read":
+		s.Reads++
+		if content, ok := readContent(input, toolUseResult); ok {
+			s.setBaseline(content)
+		}
+	case "Write":
+		s.Writes++
+		if recording {
+			s.setBase(toolUseResult["type"] == "create")
+			s.recordPatch(toolUseResult)
+		}
+		s.setBaseline(input.Content)
+	case "Edit":
+		s.Edits++
+		s.baselineFromResult(toolUseResult)
+		if recording {
+			s.setBase(!s.known && input.OldString == "")
+			s.recordPatch(toolUseResult)
+		}
+		s.applyEdit(input.FileEdit)
+	case "MultiEdit":
+		s.Edits++
+		s.baselineFromResult(toolUseResult)
+		if recording {
+			s.setBase(false)
+			s.recordPatch(toolUseResult)
+		}
+		for _, edit := range input.Edits {
+			s.applyEdit(edit)
+		}
+	}
+}
+
+// setBase records the content before the first recorded change;
+// a created file starts out empty
+func (s *fileState) setBase(created bool) {
+	if s.baseSet {
+		return
+	}
+	s.baseSet, s.created = true, created
+	if created {
+		s.base, s.baseKnown = "", true
+	} else {
+		s.base, s.baseKnown = s.content, s.known
+	}
+}
+
+// recordPatch keeps the structured patch of a recorded change
+func (s *fileState) recordPatch(toolUseResult map[string]interface{}) {
+	if hunks := structuredPatch(toolUseResult); len(hunks) > 0 {
+		s.patches = append(s.patches, hunks)
+	} else {
+		s.unpatched++
+	}
+}
+
+// setBaseline takes content read or written in full as the current state
+func (s *fileState) setBaseline(content string) {
+	s.content, s.known, s.drift = content, true, ""
+	s.updateStatus()
+}
+
+// baselineFromResult uses the original file recorded in an edit result
+// when the content is not known yet
+func (s *fileState) baselineFromResult(toolUseResult map[string]interface{}) {
+	if s.known {
+		return
+	}
+	for _, key := range []string{"originalFile", "originalFileContents"} {
+		if content, ok := toolUseResult[key].(string); ok {
+			s.setBaseline(content)
+			return
+		}
+	}
+}
+
+// applyEdit replaces old_string with new_string in the known content
+func (s *fileState) applyEdit(edit transcript.FileEdit) {
+	oldString, newString := edit.OldString, edit.NewString
+
+	switch {
+	case !s.known && oldString == "":
+		// An empty old_string creates the file
+		s.content, s.known = newString, true
+	case !s.known:
+		s.drift = filePartial
+	case oldString == "" || !strings.Contains(s.content, oldString):
+		s.Conflicts++
+		s.drift = fileConflict
+	case edit.ReplaceAll:
+		s.content = strings.ReplaceAll(s.content, oldString, newString)
+	default:
+		s.content = strings.Replace(s.content, oldString, newString, 1)
+	}
+	s.updateStatus()
+}
+
+// updateStatus derives the status from the content and any lost edits
+func (s *fileState) updateStatus() {
+	switch {
+	case s.drift != "":
+		s.Status = s.drift
+	case s.known:
+		s.Status = fileComplete
+	default:
+		s.Status = filePartial
+	}
+}
+
+// readContent extracts the whole file from a Read result. Reads of part of
+// a file, by offset or limit, are not a usable baseline.
+func readContent(input transcript.FileInput, toolUseResult map[string]interface{}) (string, bool) {
+	if input.Offset != nil || input.Limit != nil {
+		return "", false
+	}
+
+	file, ok := toolUseResult["file"].(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	content, ok := file["content"].(string)
+	if !ok {
+		return "", false
+	}
+	numLines, _ := file["numLines"].(float64)
+	totalLines, _ := file["totalLines"].(float64)
+	if numLines < totalLines {
+		return "", false
+	}
+	return content, true
+}
+
+// findFile looks up a file by path, absolute, relative to the current
+// directory, or as a unique suffix of a recorded path
+func (r *fileReplay) findFile(path string) (*fileState, error) {
+	if state, ok := r.files[path]; ok {
+		return state, nil
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		if state, ok := r.files[abs]; ok {
+			return state, nil
+		}
+	}
+
+	var matches []*fileState
+	suffix := "/" + strings.TrimPrefix(filepath.ToSlash(path), "./")
+	for _, p := range r.order {
+		if strings.HasSuffix(filepath.ToSlash(p), suffix) {
+			matches = append(matches, r.files[p])
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%s was not touched in this session", path)
+	case 1:
+		return matches[0], nil
+	}
+	var paths []string
+	for _, m := range matches {
+		paths = append(paths, m.Path)
+	}
+	return nil, fmt.Errorf("%s is ambiguous: %s", path, strings.Join(paths, ", "))
+}
+
+// runFilesCommand runs the files subcommand
+func runFilesCommand(args []string) {
+	filesCmd := flag.NewFlagSet("files", flag.ExitOnError)
+	filesCmd.StringVar(&cfg.ProjectPath, "p", "", "path to Claude Code project file")
+	filesCmd.BoolVar(&cfg.NoColor, "no-color", false, "disable color output")
+	show := filesCmd.String("show", "", "print the reconstructed content of a file")
+	jsonFlag := filesCmd.Bool("json", false, "output in JSON format")
+
+	filesCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ccl files [options] [file]\n\n")
+		fmt.Fprintf(os.Stderr, "List files changed by a session, replaying its Write, Edit and MultiEdit\n")
+		fmt.Fprintf(os.Stderr, "calls on top of the contents seen by Read.\n")
+		fmt.Fprintf(os.Stderr, "Defaults to the latest session of the current directory.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		filesCmd.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  ccl files\n")
+		fmt.Fprintf(os.Stderr, "  ccl files --show main.go > main.go.recovered\n")
+	}
+
+	if err := filesCmd.Parse(args); err != nil {
+		return
+	}
+	if *jsonFlag {
+		cfg.NoColor = true
+	}
+
+	reader, cleanup, err := getInputReader(filesCmd)
+	if cleanup != nil {
+		defer cleanup()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	entries, err := transcript.ReadAll(reader)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	replay := replayFiles(entries)
+
+	if *show != "" {
+		state, err := replay.findFile(*show)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !state.known {
+			fmt.Fprintf(os.Stderr, "Error: content of %s is unknown: it was edited without a full Read or Write\n", state.Path)
+			os.Exit(1)
+		}
+		if state.Status != fileComplete {
+			fmt.Fprintf(os.Stderr, "Warning: %s is %s; the content may differ from the file on disk\n", state.Path, state.Status)
+		}
+		fmt.Fprint(output, state.content)
+		return
+	}
+
+	files := replay.changed()
+	if *jsonFlag {
+		if files == nil {
+			files = []*fileState{}
+		}
+		jsonData, _ := json.MarshalIndent(files, "", "  ")
+		fmt.Fprintln(output, string(jsonData))
+		return
+	}
+	displayFileStates(files)
+}
+
+// displayFileStates prints the changed files as a table
+func displayFileStates(files []*fileState) {
+	if len(files) == 0 {
+		fmt.Fprintf(output, "No files changed\n")
+		return
+	}
+
+	fmt.Fprintf(output, "%s%5s %6s %6s  %-8s  %s%s\n",
+		color(colorBold), "EDITS", "WRITES", "FAILED", "STATUS", "PATH", color(colorReset))
+	for _, state := range files {
+		statusColor := colorGreen
+		switch state.Status {
+		case filePartial:
+			statusColor = colorYellow
+		case fileConflict:
+			statusColor = colorRed
+		}
+		fmt.Fprintf(output, "%5d %6d %6d  %s%-8s%s  %s\n",
+			state.Edits, state.Writes, state.Failed,
+			color(statusColor), state.Status, color(colorReset), state.Path)
+	}
+}
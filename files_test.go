@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+func TestReplayFiles(t *testing.T) {
+	input := strings.Join([]string{
+		// Full read, then an edit and a failed edit
+		`{"type":"assistant","cwd":"/work","message":{"content":[{"type":"tool_use","id":"r1","name":"Read","input":{"file_path":"/work/main.go"}}]}}`,
+		`{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"r1","content":"..."}]},"toolUseResult":{"type":"text","file":{"filePath":"/work/main.go","content":"a\nb\nc\n","numLines":3,"startLine":1,"totalLines":3}}}`,
+		`{"type":"assistant","cwd":"/work","message":{"content":[{"type":"tool_use","id":"e1","name":"Edit","input":{"file_path":"main.go","old_string":"b","new_string":"B"}},{"type":"tool_use","id":"e2","name":"Edit","input":{"file_path":"/work/main.go","old_string":"c","new_string":"C"}}]}}`,
+		`{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"e1","content":"ok"},{"type":"tool_result","tool_use_id":"e2","content":"denied","is_error":true}]}}`,
+		// Write, then a MultiEdit with replace_all
+		`{"type":"assistant","cwd":"/work","message":{"content":[{"type":"tool_use","id":"w1","name":"Write","input":{"file_path":"/work/new.txt","content":"x x\ny\n"}},{"type":"tool_use","id":"m1","name":"MultiEdit","input":{"file_path":"/work/new.txt","edits":[{"old_string":"x","new_string":"z","replace_all":true},{"old_string":"y","new_string":"w"}]}}]}}`,
+		`{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"w1","content":"ok"},{"type":"tool_result","tool_use_id":"m1","content":"ok"}]}}`,
+		// Edits without a baseline, one of which does not match after a read
+		`{"type":"assistant","cwd":"/work","message":{"content":[{"type":"tool_use","id":"e3","name":"Edit","input":{"file_path":"/work/unknown.go","old_string":"p","new_string":"q"}},{"type":"tool_use","id":"e4","name":"Edit","input":{"file_path":"/work/main.go","old_string":"missing","new_string":"m"}}]}}`,
+		`{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"e3","content":"ok"},{"type":"tool_result","tool_use_id":"e4","content":"ok"}]}}`,
+		// Read only, never changed; and a call that never got a result
+		`{"type":"assistant","cwd":"/work","message":{"content":[{"type":"tool_use","id":"r2","name":"Read","input":{"file_path":"/work/README"}},{"type":"tool_use","id":"w2","name":"Write","input":{"file_path":"/work/pending.txt","content":"?"}}]}}`,
+		`{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"r2","content":"..."}]}}`,
+	}, "\n")
+	entries, err := transcript.ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	replay := replayFiles(entries)
+
+	tests := map[string]struct {
+		path    string
+		edits   int
+		writes  int
+		failed  int
+		status  string
+		content string
+	}{
+		"edited after read":       {"/work/main.go", 2, 0, 1, fileConflict, "a\nB\nc\n"},
+		"written then edited":     {"/work/new.txt", 1, 1, 0, fileComplete, "z z\nw\n"},
+		"edited without baseline": {"/work/unknown.go", 1, 0, 0, filePartial, ""},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			state, err := replay.findFile(tt.path)
+			if err != nil {
+				t.Fatalf("findFile(%q) error = %v", tt.path, err)
+			}
+			if state.Edits != tt.edits || state.Writes != tt.writes || state.Failed != tt.failed || state.Status != tt.status {
+				t.Errorf("state = %+v; want edits %d, writes %d, failed %d, status %s",
+					state, tt.edits, tt.writes, tt.failed, tt.status)
+			}
+			if state.content != tt.content {
+				t.Errorf("content = %q; want %q", state.content, tt.content)
+			}
+		})
+	}
+
+	var paths []string
+	for _, state := range replay.changed() {
+		paths = append(paths, state.Path)
+	}
+	if got := strings.Join(paths, ","); got != "/work/main.go,/work/new.txt,/work/unknown.go" {
+		t.Errorf("changed() = %s", got)
+	}
+
+	if state, err := replay.findFile("new.txt"); err != nil || state.Path != "/work/new.txt" {
+		t.Errorf("findFile(new.txt) = %v, %v; want /work/new.txt", state, err)
+	}
+}
@@ -1,6 +1,10 @@
 package main
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/Sixeight/ccl/transcript"
+)
 
 // Parse comma-separated strings
 func parseCommaSeparated(str string) []string {
@@ -19,42 +23,89 @@ func parseCommaSeparated(str string) []string {
 	return result
 }
 
+// entryFilter holds the role and tool filters entries are displayed under
+type entryFilter struct {
+	roles   []string
+	tools   []string
+	exclude []string
+}
+
+// newEntryFilter parses comma-separated --role, --tool and --tool-exclude values
+func newEntryFilter(role, tool, toolExclude string) entryFilter {
+	return entryFilter{
+		roles:   parseCommaSeparated(role),
+		tools:   parseCommaSeparated(tool),
+		exclude: parseCommaSeparated(toolExclude),
+	}
+}
+
+// cfgEntryFilter returns the filters given on the command line
+func cfgEntryFilter() entryFilter {
+	return newEntryFilter(cfg.Role, cfg.ToolFilter, cfg.ToolExclude)
+}
+
+// hasToolFilters reports whether a tool filter or exclusion is set
+func (f entryFilter) hasToolFilters() bool {
+	return len(f.tools) > 0 || len(f.exclude) > 0
+}
+
+// matchTool checks if a tool name passes the tool filters
+func (f entryFilter) matchTool(toolName string) bool {
+	return applyToolFilters(toolName, f.tools, f.exclude)
+}
+
 // Check if an entry should be displayed based on role filters
-func shouldDisplayEntry(msgType string, entry map[string]interface{}) bool {
-	// Parse filter list
-	filterRoles := parseCommaSeparated(cfg.Role)
+func shouldDisplayEntry(msgType string, entry *transcript.Entry) bool {
+	return cfgEntryFilter().matchRole(msgType, entry)
+}
+
+// Check if an entry should be displayed based on all filters
+func shouldDisplayEntryWithToolInfo(msgType string, entry *transcript.Entry, tools transcript.ToolIndex) bool {
+	return cfgEntryFilter().match(msgType, entry, tools)
+}
 
+// matchRole checks if an entry passes the role filters
+func (f entryFilter) matchRole(msgType string, entry *transcript.Entry) bool {
 	// If no filter specified, display all
-	if len(filterRoles) == 0 {
+	if len(f.roles) == 0 {
 		return true
 	}
 
 	// Check if message type is in filter list
-	for _, role := range filterRoles {
+	for _, role := range f.roles {
 		if msgType == role {
 			return true
 		}
+		// The thinking pseudo-role selects assistant messages that think
+		if role == "thinking" && msgType == "assistant" && entry != nil &&
+			hasThinking(entry.Message) && !logConfig.noThinking {
+			return true
+		}
 	}
 
 	return false
 }
 
-// Check if an entry should be displayed based on all filters
-func shouldDisplayEntryWithToolInfo(msgType string, entry map[string]interface{}, toolUseMap map[string]string) bool {
-	// Check if tool filters are specified
-	toolFilterList := parseCommaSeparated(cfg.ToolFilter)
-	toolExcludeList := parseCommaSeparated(cfg.ToolExclude)
-	hasToolFilters := len(toolFilterList) > 0 || len(toolExcludeList) > 0
+// match checks if an entry passes all filters
+func (f entryFilter) match(msgType string, entry *transcript.Entry, tools transcript.ToolIndex) bool {
+	// The --where expression applies on top of all other filters
+	if !matchWhere(logConfig.where, entry, tools) {
+		return false
+	}
+	// Nothing is left of hidden thinking
+	if logConfig.noThinking && isOnlyThinking(entry.Message) {
+		return false
+	}
 
 	// If tool filters are specified, prioritize tool-based filtering
-	if hasToolFilters {
+	if f.hasToolFilters() {
 		switch msgType {
 		case "user":
-			return shouldDisplayUserWithToolResult(entry, toolUseMap)
+			return f.matchUserWithToolResult(entry, tools)
 		case "assistant":
-			return shouldDisplayAssistantWithTools(entry, toolUseMap)
+			return f.matchAssistantWithTools(entry, tools)
 		case "tool":
-			return shouldDisplayToolResult(entry, toolUseMap)
+			return f.matchToolResult(entry, tools)
 		default:
 			// For other message types, don't display when tool filters are active
 			return false
@@ -64,22 +115,22 @@ func shouldDisplayEntryWithToolInfo(msgType string, entry map[string]interface{}
 	// If no tool filters, fall back to role-based filtering
 	// Special handling for user messages that might contain tool results
 	if msgType == "user" {
-		return shouldDisplayUserWithToolResult(entry, toolUseMap)
+		return f.matchUserWithToolResult(entry, tools)
 	}
 
 	// First check role filters for non-user messages
-	if !shouldDisplayEntry(msgType, entry) {
+	if !f.matchRole(msgType, entry) {
 		return false
 	}
 
 	// For tool messages, check tool filters
 	if msgType == "tool" {
-		return shouldDisplayToolResult(entry, toolUseMap)
+		return f.matchToolResult(entry, tools)
 	}
 
 	// For assistant messages, check if they contain filtered tools
 	if msgType == "assistant" {
-		return shouldDisplayAssistantWithTools(entry, toolUseMap)
+		return f.matchAssistantWithTools(entry, tools)
 	}
 
 	// For other message types, display if role filter passed
@@ -87,38 +138,29 @@ func shouldDisplayEntryWithToolInfo(msgType string, entry map[string]interface{}
 }
 
 // Check if a tool result should be displayed
-func shouldDisplayToolResult(entry map[string]interface{}, toolUseMap map[string]string) bool {
-	toolFilterList := parseCommaSeparated(cfg.ToolFilter)
-	toolExcludeList := parseCommaSeparated(cfg.ToolExclude)
-
+func (f entryFilter) matchToolResult(entry *transcript.Entry, tools transcript.ToolIndex) bool {
 	// Get tool name from parent message ID
-	parentID, _ := entry["parentMessageId"].(string)
-	toolName := toolUseMap[parentID]
+	toolName := tools.Name(entry.ParentMessageID)
 
 	// If we couldn't determine tool name, apply default behavior
 	if toolName == "" {
 		// If there's a tool filter, don't show unknown tools
-		if len(toolFilterList) > 0 {
+		if len(f.tools) > 0 {
 			return false
 		}
 		// If there's no filter, show it (unless excluded)
 		return true
 	}
 
-	return applyToolFilters(toolName, toolFilterList, toolExcludeList)
+	return f.matchTool(toolName)
 }
 
 // Get tool name from content item
-func getToolName(m map[string]interface{}, toolUseMap map[string]string) string {
-	if id, ok := m["id"].(string); ok {
-		if toolName := toolUseMap[id]; toolName != "" {
-			return toolName
-		}
-	}
-	if name, ok := m["name"].(string); ok {
-		return name
+func getToolName(block *transcript.ContentBlock, tools transcript.ToolIndex) string {
+	if toolName := tools.Name(block.ID); toolName != "" {
+		return toolName
 	}
-	return ""
+	return block.Name
 }
 
 // Check if tool is excluded
@@ -156,35 +198,26 @@ func applyToolFilters(toolName string, includeList, excludeList []string) bool {
 }
 
 // Check if an assistant message with tools should be displayed
-func shouldDisplayAssistantWithTools(entry map[string]interface{}, toolUseMap map[string]string) bool {
-	toolFilterList := parseCommaSeparated(cfg.ToolFilter)
-	toolExcludeList := parseCommaSeparated(cfg.ToolExclude)
-
+func (f entryFilter) matchAssistantWithTools(entry *transcript.Entry, tools transcript.ToolIndex) bool {
 	// If no tool filters, show all assistant messages
-	if len(toolFilterList) == 0 && len(toolExcludeList) == 0 {
+	if !f.hasToolFilters() {
 		return true
 	}
 
 	// Extract tool uses from message
-	message, ok := entry["message"].(map[string]interface{})
-	if !ok {
-		return true
-	}
-
-	content, ok := message["content"].([]interface{})
-	if !ok {
+	if entry.Message == nil {
 		return true
 	}
 
 	// Check if any tool passes filters
-	for _, item := range content {
-		m, ok := item.(map[string]interface{})
-		if !ok || m["type"] != "tool_use" {
+	for i := range entry.Message.Content {
+		block := &entry.Message.Content[i]
+		if block.Type != transcript.BlockToolUse {
 			continue
 		}
 
-		toolName := getToolName(m, toolUseMap)
-		if applyToolFilters(toolName, toolFilterList, toolExcludeList) {
+		toolName := getToolName(block, tools)
+		if f.matchTool(toolName) {
 			return true
 		}
 	}
@@ -193,30 +226,25 @@ func shouldDisplayAssistantWithTools(entry map[string]interface{}, toolUseMap ma
 }
 
 // Check if a user message with tool results should be displayed
-func shouldDisplayUserWithToolResult(entry map[string]interface{}, toolUseMap map[string]string) bool {
-	filterRoles := parseCommaSeparated(cfg.Role)
-	toolFilterList := parseCommaSeparated(cfg.ToolFilter)
-	toolExcludeList := parseCommaSeparated(cfg.ToolExclude)
-	hasToolFilters := len(toolFilterList) > 0 || len(toolExcludeList) > 0
-
+func (f entryFilter) matchUserWithToolResult(entry *transcript.Entry, tools transcript.ToolIndex) bool {
 	// If tool filters are specified, only show user messages with matching tool results
-	if hasToolFilters {
+	if f.hasToolFilters() {
 		if hasToolResult(entry) {
-			return shouldDisplayToolResultInUser(entry, toolUseMap)
+			return f.matchToolResultInUser(entry, tools)
 		}
 		// Regular user messages are not shown when tool filters are active
 		return false
 	}
 
 	// If no role filters, show all
-	if len(filterRoles) == 0 {
+	if len(f.roles) == 0 {
 		return true
 	}
 
 	// Check if "tool" is in filter and this has tool result
 	hasToolFilter := false
 	hasUserFilter := false
-	for _, role := range filterRoles {
+	for _, role := range f.roles {
 		if role == "tool" {
 			hasToolFilter = true
 		}
@@ -228,7 +256,7 @@ func shouldDisplayUserWithToolResult(entry map[string]interface{}, toolUseMap ma
 	if hasToolResult(entry) {
 		// This is a tool result, show if tool is in filter
 		if hasToolFilter {
-			return shouldDisplayToolResultInUser(entry, toolUseMap)
+			return f.matchToolResultInUser(entry, tools)
 		}
 	} else {
 		// This is a regular user message, show if user is in filter
@@ -240,66 +268,33 @@ func shouldDisplayUserWithToolResult(entry map[string]interface{}, toolUseMap ma
 }
 
 // Check if a user message contains tool results
-func hasToolResult(entry map[string]interface{}) bool {
-	message, ok := entry["message"].(map[string]interface{})
-	if !ok {
-		return false
-	}
-
-	content, ok := message["content"].([]interface{})
-	if !ok {
-		return false
-	}
-
-	for _, item := range content {
-		if m, ok := item.(map[string]interface{}); ok {
-			if m["type"] == "tool_result" {
-				return true
-			}
-		}
-	}
-	return false
+func hasToolResult(entry *transcript.Entry) bool {
+	return entry.HasToolResult()
 }
 
 // Check if a tool result in a user message should be displayed
-func shouldDisplayToolResultInUser(entry map[string]interface{}, toolUseMap map[string]string) bool {
-	toolFilterList := parseCommaSeparated(cfg.ToolFilter)
-	toolExcludeList := parseCommaSeparated(cfg.ToolExclude)
-
-	message, ok := entry["message"].(map[string]interface{})
-	if !ok {
-		return true
-	}
-
-	content, ok := message["content"].([]interface{})
-	if !ok {
+func (f entryFilter) matchToolResultInUser(entry *transcript.Entry, tools transcript.ToolIndex) bool {
+	if entry.Message == nil {
 		return true
 	}
 
 	// Find tool_result and get tool name
 	var toolName string
-	for _, item := range content {
-		if m, ok := item.(map[string]interface{}); ok {
-			if m["type"] == "tool_result" {
-				if toolUseID, ok := m["tool_use_id"].(string); ok {
-					toolName = toolUseMap[toolUseID]
-				}
-				break
-			}
-		}
+	if result := entry.Message.Content.FirstOfType(transcript.BlockToolResult); result != nil {
+		toolName = tools.Name(result.ToolUseID)
 	}
 
 	// If we couldn't determine tool name, apply default behavior
 	if toolName == "" {
 		// If there's a tool filter, don't show unknown tools
-		if len(toolFilterList) > 0 {
+		if len(f.tools) > 0 {
 			return false
 		}
 		// If there's no filter, show it (unless excluded)
 		return true
 	}
 
-	return applyToolFilters(toolName, toolFilterList, toolExcludeList)
+	return f.matchTool(toolName)
 }
 
 // Match glob pattern against string
@@ -0,0 +1,479 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Sixeight/ccl/projects"
+	"github.com/Sixeight/ccl/transcript"
+)
+
+// liveRenderer displays entries one at a time as they arrive, keeping
+// the state that spans entries
+type liveRenderer struct {
+	tools    transcript.ToolIndex // Persists across all entries
+	rng      *rangeFilter         // Range state continues into new entries
+	grep     *grepFilter
+	totals   *sessionStats
+	activity *sessionActivity
+	status   *statusLine // With --status
+}
+
+// newLiveRenderer returns a renderer that has not seen any entries
+func newLiveRenderer() *liveRenderer {
+	return &liveRenderer{
+		tools:    make(transcript.ToolIndex),
+		rng:      logConfig.rng.filter(),
+		grep:     newGrepFilter(logConfig.grep, logConfig.grepContext),
+		totals:   newSessionStats(),
+		activity: &sessionActivity{},
+	}
+}
+
+// collect takes an entry into account without displaying it
+func (r *liveRenderer) collect(entry *transcript.Entry) {
+	r.tools.Collect(entry)
+	r.totals.add(entry, r.tools)
+	r.activity.add(entry)
+}
+
+// display shows an entry already collected
+func (r *liveRenderer) display(entry *transcript.Entry) {
+	if r.rng.allow(entry) {
+		displayEntryWithGrep(entry, r.tools, r.grep)
+	}
+}
+
+// render collects and shows an entry
+func (r *liveRenderer) render(entry *transcript.Entry) {
+	r.collect(entry)
+	r.display(entry)
+}
+
+// showStatus starts showing a status line with the output, with --status
+func (r *liveRenderer) showStatus() {
+	if !logConfig.status || cfg.OutputFormat != "text" {
+		return
+	}
+	r.status = newStatusLine(output)
+	output = r.status
+}
+
+// updateStatus redraws the status line, if shown
+func (r *liveRenderer) updateStatus() {
+	if r.status != nil {
+		r.status.update(statusText(r.totals, r.activity, time.Now()))
+	}
+}
+
+// stopStatus removes the status line, if shown
+func (r *liveRenderer) stopStatus() {
+	if r.status != nil {
+		r.status.close()
+		output = r.status.w
+		r.status = nil
+	}
+}
+
+// displayTotals prints the running totals of the entries seen
+func (r *liveRenderer) displayTotals() {
+	r.stopStatus()
+	if cfg.OutputFormat != "text" {
+		return
+	}
+	fmt.Fprintf(output, "\n%s── %s ──%s\n", color(colorBold), totalsSummary(r.totals), color(colorReset))
+}
+
+// totalsSummary returns the running totals of a session on one line
+func totalsSummary(stats *sessionStats) string {
+	parts := []string{
+		fmt.Sprintf("%s prompt%s", formatNumber(stats.UserPrompts), pluralize(stats.UserPrompts)),
+		fmt.Sprintf("%s turn%s", formatNumber(stats.AssistantTurns), pluralize(stats.AssistantTurns)),
+	}
+	calls := fmt.Sprintf("%s tool call%s", formatNumber(stats.totalToolCalls()), pluralize(stats.totalToolCalls()))
+	if stats.ErrorResults > 0 {
+		calls += fmt.Sprintf(" (%d error%s)", stats.ErrorResults, pluralize(stats.ErrorResults))
+	}
+	parts = append(parts, calls, formatNumber(stats.Usage.Total())+" tokens")
+	if cfg.ShowCost {
+		calculateStatsCost(stats)
+		parts = append(parts, fmt.Sprintf("$%.4f", stats.Cost))
+	}
+	if !stats.Start.IsZero() {
+		parts = append(parts, formatElapsed(stats.End.Sub(stats.Start)))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// followedSession holds the state for displaying entries as they are
+// appended to a session file
+type followedSession struct {
+	*liveRenderer
+	path  string
+	tail  *tailer
+	isNew bool   // The file was created after following started
+	dir   string // Project directory, with a project follower
+	color string // Color of the line prefix, with watch --all
+}
+
+// followSession displays what a session file holds so far and returns
+// the state for following it
+func followSession(file *os.File) (*followedSession, error) {
+	// Existing content up to the last newline; a line still being
+	// written is read by the tailer once it is complete
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading file: %w", err)
+	}
+	existing := data[:bytes.LastIndexByte(data, '\n')+1]
+
+	if err := processBuffered(bytes.NewReader(existing)); err != nil {
+		return nil, err
+	}
+	return resumeSession(file, int64(len(existing))), nil
+}
+
+// resumeSession follows a session file from offset, taking the tool calls
+// and turns before it into account
+func resumeSession(file *os.File, offset int64) *followedSession {
+	s := &followedSession{
+		liveRenderer: newLiveRenderer(),
+		path:         file.Name(),
+		tail:         newTailer(file, offset),
+	}
+	reader := transcript.NewReader(io.NewSectionReader(file, 0, offset))
+	for reader.Next() {
+		entry := reader.Entry()
+		s.collect(entry)
+		s.rng.allow(entry)
+	}
+	return s
+}
+
+// newEntries returns the entries appended since the last call,
+// with their tool calls collected
+func (s *followedSession) newEntries() ([]*transcript.Entry, error) {
+	lines, err := s.tail.lines()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*transcript.Entry, 0, len(lines))
+	for _, line := range lines {
+		entry, err := transcript.ParseEntry(line)
+		if err != nil {
+			continue // Skip malformed lines
+		}
+		s.collect(entry)
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// id returns the session ID, taken from the file name
+func (s *followedSession) id() string {
+	return strings.TrimSuffix(filepath.Base(s.path), ".jsonl")
+}
+
+// headerWriter writes a header before the first write through it
+type headerWriter struct {
+	w       io.Writer
+	header  string
+	written bool
+}
+
+func (h *headerWriter) Write(b []byte) (int, error) {
+	if !h.written {
+		h.written = true
+		if _, err := io.WriteString(h.w, h.header); err != nil {
+			return 0, err
+		}
+	}
+	return h.w.Write(b)
+}
+
+// projectFollower follows every session file of project directories,
+// picking up sessions started (e.g. after /clear) or resumed while it runs
+type projectFollower struct {
+	dirs     []string
+	watcher  *fileWatcher     // nil when polling
+	known    map[string]int64 // Size of the files not followed, when first seen
+	sessions map[string]*followedSession
+	current  *followedSession // Session whose entries were shown last
+
+	// With watch --all: new or newly active project directories
+	// under root are followed too
+	root        string
+	rootChecked time.Time
+	started     time.Time
+
+	// Prefix lines with the project name in a color per session
+	// instead of showing a banner at each switch
+	prefix bool
+	names  map[string]string // Project directory -> display name
+	width  int               // Length of the longest name
+	colors int               // Session colors handed out
+}
+
+// Colors told apart sessions shown together
+var sessionColors = []string{colorCyan, colorGreen, colorYellow, colorBlue, colorPurple, colorRed}
+
+// newFollower creates a follower without any directories
+func newFollower() *projectFollower {
+	f := &projectFollower{
+		known:    make(map[string]int64),
+		sessions: make(map[string]*followedSession),
+		names:    make(map[string]string),
+		started:  time.Now(),
+	}
+	if watcher, err := newFileWatcher(); err == nil {
+		f.watcher = watcher
+	}
+	return f
+}
+
+// newProjectFollower follows the sessions in dir, starting with the
+// existing content of first (if not "")
+func newProjectFollower(dir, first string) (*projectFollower, error) {
+	f := newFollower()
+	if _, err := f.remember(dir); err != nil {
+		f.Close()
+		return nil, err
+	}
+	f.follow(dir)
+
+	if first != "" {
+		file, err := os.Open(first)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("opening file: %w", err)
+		}
+		s, err := followSession(file)
+		if err != nil {
+			_ = file.Close()
+			f.Close()
+			return nil, err
+		}
+		f.sessions[first] = s
+		f.current = s
+	}
+	return f, nil
+}
+
+// remember records the size of the session files in dir, so that only
+// what is appended to them later is shown. It returns when the most
+// recent of them was modified.
+func (f *projectFollower) remember(dir string) (time.Time, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("reading project directory: %w", err)
+	}
+	var latest time.Time
+	for _, file := range files {
+		info, err := file.Info()
+		if err != nil || !isSessionFile(file) {
+			continue
+		}
+		f.known[filepath.Join(dir, file.Name())] = info.Size()
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// follow starts following the session files in dir
+func (f *projectFollower) follow(dir string) {
+	f.dirs = append(f.dirs, dir)
+	if f.watcher != nil {
+		_ = f.watcher.watchDir(dir) // Still noticed on the periodic check
+	}
+
+	if f.prefix {
+		f.nameProjects()
+	}
+}
+
+// nameProjects gives the project directories followed short display names,
+// telling apart projects with the same last directory name
+func (f *projectFollower) nameProjects() {
+	sessions := make([]projects.Session, len(f.dirs))
+	for i, dir := range f.dirs {
+		sessions[i].Project = projects.DecodePath(filepath.Base(dir))
+	}
+	projects.ShortenNames(sessions)
+	for i, dir := range f.dirs {
+		f.names[dir] = sessions[i].Display
+		f.width = max(f.width, displayWidth(sessions[i].Display))
+	}
+}
+
+// isSessionFile reports whether a directory entry is a session transcript
+func isSessionFile(file os.DirEntry) bool {
+	return !file.IsDir() && strings.HasSuffix(file.Name(), ".jsonl")
+}
+
+// scan starts following session files that were created or have grown
+func (f *projectFollower) scan() error {
+	if f.root != "" {
+		f.scanRoot()
+	}
+	for _, dir := range f.dirs {
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			if f.root != "" {
+				continue // A project directory may be removed
+			}
+			return fmt.Errorf("reading project directory: %w", err)
+		}
+		for _, entry := range files {
+			path := filepath.Join(dir, entry.Name())
+			if !isSessionFile(entry) || f.sessions[path] != nil {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			size, seen := f.known[path]
+			if seen && info.Size() == size {
+				continue
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				continue // Try again on the next scan
+			}
+			s := resumeSession(file, size)
+			s.isNew = !seen
+			s.dir = dir
+			s.color = sessionColors[f.colors%len(sessionColors)]
+			f.colors++
+			f.sessions[path] = s
+		}
+	}
+	return nil
+}
+
+// sessionEntry is an entry together with the session it was appended to
+type sessionEntry struct {
+	session *followedSession
+	entry   *transcript.Entry
+}
+
+// update displays the entries appended to any session since the last call,
+// in the order of their timestamps
+func (f *projectFollower) update() error {
+	if err := f.scan(); err != nil {
+		return err
+	}
+
+	var pending []sessionEntry
+	for _, s := range f.sessions {
+		entries, err := s.newEntries()
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			pending = append(pending, sessionEntry{s, entry})
+		}
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		if pending[i].entry.Timestamp != pending[j].entry.Timestamp {
+			return pending[i].entry.Timestamp < pending[j].entry.Timestamp
+		}
+		return pending[i].session.path < pending[j].session.path
+	})
+
+	for _, p := range pending {
+		f.display(p.session, p.entry)
+	}
+	return nil
+}
+
+// display shows an entry, preceded by a banner when it is the first shown
+// from a different session than the previous one
+func (f *projectFollower) display(s *followedSession, entry *transcript.Entry) {
+	if cfg.OutputFormat != "text" {
+		s.display(entry)
+		return
+	}
+
+	stdout, last := output, lastTimestamp
+	defer func() { output = stdout }()
+	if f.prefix {
+		name := f.names[s.dir]
+		prefix := color(s.color) + name + color(colorReset) +
+			strings.Repeat(" ", f.width-displayWidth(name)) + " │ "
+		output = &prefixWriter{w: stdout, prefix: prefix}
+	}
+	if s == f.current {
+		s.display(entry)
+		return
+	}
+
+	// Time since a message of another session means nothing
+	header := ""
+	if !f.prefix {
+		header = sessionBanner(s)
+	}
+	shown := &headerWriter{w: output, header: header}
+	output, lastTimestamp = shown, time.Time{}
+	s.display(entry)
+
+	if shown.written {
+		f.current = s
+	} else {
+		lastTimestamp = last
+	}
+}
+
+// sessionBanner returns the line marking the switch to another session
+func sessionBanner(s *followedSession) string {
+	label := "session"
+	if s.isNew {
+		label = "new session"
+	}
+	return fmt.Sprintf("\n%s── %s %s ──%s\n\n", color(colorCyan+colorBold), label, s.id(), color(colorReset))
+}
+
+// run displays new entries until interrupted
+func (f *projectFollower) run() error {
+	// Stop cleanly on Ctrl-C
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	for {
+		if err := f.update(); err != nil {
+			return err
+		}
+		if !f.wait(stop) {
+			return nil
+		}
+	}
+}
+
+// wait blocks until a session file may have changed; it returns false once
+// stop receives a value
+func (f *projectFollower) wait(stop <-chan os.Signal) bool {
+	return waitForChange(&f.watcher, stop)
+}
+
+// Close stops following all sessions
+func (f *projectFollower) Close() {
+	if f.watcher != nil {
+		_ = f.watcher.Close()
+	}
+	for _, s := range f.sessions {
+		s.tail.Close()
+		_ = s.tail.file.Close()
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProjectFollower(t *testing.T) {
+	origCfg, origLogConfig, origOutput := cfg, logConfig, output
+	defer func() {
+		cfg, logConfig, output = origCfg, origLogConfig, origOutput
+	}()
+	cfg = Config{NoColor: true, OutputFormat: "text"}
+	logConfig = LogConfig{}
+
+	dir := t.TempDir()
+	write := func(name string, lines ...string) {
+		t.Helper()
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = f.Close() }()
+		for _, line := range lines {
+			if _, err := f.WriteString(line + "\n"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	prompt := func(uuid, text, ts string) string {
+		return `{"type":"user","uuid":"` + uuid + `","timestamp":"` + ts +
+			`","message":{"role":"user","content":"` + text + `"}}`
+	}
+
+	write("first.jsonl", prompt("u1", "existing prompt", "2025-06-22T09:00:00Z"))
+	write("idle.jsonl", prompt("i1", "idle prompt", "2025-06-21T09:00:00Z"))
+
+	var buf bytes.Buffer
+	output = &buf
+	follower, err := newProjectFollower(dir, filepath.Join(dir, "first.jsonl"))
+	if err != nil {
+		t.Fatalf("newProjectFollower() error = %v", err)
+	}
+	defer follower.Close()
+
+	update := func(step string) string {
+		t.Helper()
+		buf.Reset()
+		if err := follower.update(); err != nil {
+			t.Fatalf("%s: update() error = %v", step, err)
+		}
+		return buf.String()
+	}
+
+	if got := buf.String(); !strings.Contains(got, "existing prompt") || strings.Contains(got, "idle prompt") {
+		t.Errorf("initial output = %q; want only the first session", got)
+	}
+
+	write("first.jsonl", prompt("u2", "second prompt", "2025-06-22T09:01:00Z"))
+	if got := update("append"); !strings.Contains(got, "second prompt") || strings.Contains(got, "──") {
+		t.Errorf("appended output = %q; want the prompt without a banner", got)
+	}
+
+	// A session without messages yet gets no banner
+	write("second.jsonl", `{"type":"summary","summary":"Demo","leafUuid":"x"}`)
+	if got := update("summary only"); got != "" {
+		t.Errorf("summary-only output = %q; want none", got)
+	}
+
+	write("second.jsonl", prompt("n1", "new prompt", "2025-06-22T09:02:00Z"))
+	got := update("new session")
+	if !strings.HasPrefix(got, "\n── new session second ──\n") || !strings.Contains(got, "new prompt") {
+		t.Errorf("new session output = %q; want a banner and the prompt", got)
+	}
+
+	// Entries of several sessions are shown in time order
+	write("idle.jsonl", prompt("i2", "resumed prompt", "2025-06-22T09:04:00Z"))
+	write("second.jsonl", prompt("n2", "later prompt", "2025-06-22T09:03:00Z"))
+	got = update("resumed session")
+	if strings.Contains(got, "idle prompt") {
+		t.Errorf("resumed output = %q; want only the new entries", got)
+	}
+	later, banner, resumed := strings.Index(got, "later prompt"), strings.Index(got, "── session idle ──"), strings.Index(got, "resumed prompt")
+	if later < 0 || banner < later || resumed < banner {
+		t.Errorf("resumed output = %q; want the later prompt, then a banner and the resumed prompt", got)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+// grepFilter selects entries matching --grep, with --grep-context entries
+// around each match, from entries seen one at a time
+type grepFilter struct {
+	pattern *regexp.Regexp
+	context int
+	before  []*transcript.Entry // Up to context entries not shown yet
+	after   int                 // Entries still to show after the last match
+	shown   bool                // Some entry was shown
+	skipped bool                // An entry was left out since the last one shown
+}
+
+// newGrepFilter creates a filter for the pattern, or nil when there is none
+func newGrepFilter(pattern *regexp.Regexp, context int) *grepFilter {
+	if pattern == nil {
+		return nil
+	}
+	return &grepFilter{pattern: pattern, context: context}
+}
+
+// matches reports whether the text, tool input or tool result of an entry matches
+func (g *grepFilter) matches(entry *transcript.Entry, tools transcript.ToolIndex) bool {
+	for _, field := range searchFields(entry, tools) {
+		if g.pattern.MatchString(field.Text) {
+			return true
+		}
+	}
+
+	// Results shown under their calls match with them
+	if entry.Message != nil {
+		for i := range entry.Message.Content {
+			if result, _ := inlinedResult(&entry.Message.Content[i], tools); result != nil && g.matches(result, tools) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// feed takes the next displayable entry and returns the entries to show now,
+// and whether they are separated from the ones shown before by a gap
+func (g *grepFilter) feed(entry *transcript.Entry, tools transcript.ToolIndex) ([]*transcript.Entry, bool) {
+	if g.matches(entry, tools) {
+		show := append(g.before, entry)
+		g.before = nil
+		g.after = g.context
+		return g.emit(show)
+	}
+	if g.after > 0 {
+		g.after--
+		return g.emit([]*transcript.Entry{entry})
+	}
+
+	if g.context > 0 {
+		if len(g.before) == g.context {
+			g.before = g.before[1:]
+			g.skipped = true
+		}
+		g.before = append(g.before, entry)
+	} else {
+		g.skipped = true
+	}
+	return nil, false
+}
+
+// emit records that entries are shown and reports whether a gap precedes them
+func (g *grepFilter) emit(show []*transcript.Entry) ([]*transcript.Entry, bool) {
+	gap := g.shown && g.skipped
+	g.shown, g.skipped = true, false
+	return show, gap
+}
+
+// filter applies the filter to a whole conversation, keeping only displayable entries
+func (g *grepFilter) filter(entries []*transcript.Entry, tools transcript.ToolIndex) []*transcript.Entry {
+	if g == nil {
+		return entries
+	}
+	var kept []*transcript.Entry
+	for _, entry := range entries {
+		if !shouldDisplayEntryWithToolInfo(entry.Type, entry, tools) {
+			continue
+		}
+		show, _ := g.feed(entry, tools)
+		kept = append(kept, show...)
+	}
+	return kept
+}
+
+// displayEntryWithGrep displays an entry unless --grep leaves it out,
+// together with any context entries held back before it
+func displayEntryWithGrep(entry *transcript.Entry, tools transcript.ToolIndex, g *grepFilter) {
+	if g == nil {
+		displayEntryWithToolInfo(entry, tools)
+		return
+	}
+	if !shouldDisplayEntryWithToolInfo(entry.Type, entry, tools) {
+		return
+	}
+
+	show, gap := g.feed(entry, tools)
+	if gap && g.context > 0 && cfg.OutputFormat == "text" {
+		fmt.Fprintf(output, "%s--%s\n", color(colorGray), color(colorReset))
+	}
+	for _, e := range show {
+		displayEntryWithToolInfo(e, tools)
+	}
+}
+
+// highlightMatches marks the --grep matches in a line of displayed text
+func highlightMatches(line string) string {
+	if logConfig.grep == nil || cfg.NoColor {
+		return line
+	}
+	return logConfig.grep.ReplaceAllStringFunc(line, func(match string) string {
+		return color(colorRed+colorBold) + match + color(colorReset)
+	})
+}
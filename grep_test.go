@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+func TestGrepFilter(t *testing.T) {
+	input := strings.Join([]string{
+		`{"type":"user","uuid":"u1","message":{"role":"user","content":"build it"}}`,
+		`{"type":"assistant","uuid":"a1","message":{"content":[{"type":"tool_use","id":"x","name":"Bash","input":{"command":"make"}}]}}`,
+		`{"type":"user","uuid":"t1","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"x","content":"panic: nil map"}]}}`,
+		`{"type":"assistant","uuid":"a2","message":{"content":"Fixed."}}`,
+		`{"type":"user","uuid":"u2","message":{"role":"user","content":"thanks"}}`,
+		`{"type":"assistant","uuid":"a3","message":{"content":"Anything else?"}}`,
+		`{"type":"user","uuid":"u3","message":{"role":"user","content":"run make again"}}`,
+	}, "\n")
+	entries, err := transcript.ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	tools := transcript.NewToolIndex(entries)
+
+	tests := map[string]struct {
+		pattern  string
+		context  int
+		expected string
+		gaps     string // Entries shown right after a gap
+	}{
+		"text":           {"thanks", 0, "u2", ""},
+		"tool input":     {"^make$", 0, "a1", ""},
+		"tool result":    {"panic", 0, "t1", ""},
+		"several":        {"make", 0, "a1 u3", "u3"},
+		"context":        {"panic", 1, "a1 t1 a2", ""},
+		"split context":  {"make", 1, "u1 a1 t1 a3 u3", "a3"},
+		"joined context": {"make", 2, "u1 a1 t1 a2 u2 a3 u3", ""},
+		"no match":       {"nothing", 3, "", ""},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := newGrepFilter(regexp.MustCompile(tt.pattern), tt.context)
+			var shown, gaps []string
+			for _, entry := range entries {
+				show, gap := g.feed(entry, tools)
+				if gap {
+					gaps = append(gaps, show[0].UUID)
+				}
+				for _, e := range show {
+					shown = append(shown, e.UUID)
+				}
+			}
+			if got := strings.Join(shown, " "); got != tt.expected {
+				t.Errorf("shown = %q; want %q", got, tt.expected)
+			}
+			if got := strings.Join(gaps, " "); got != tt.gaps {
+				t.Errorf("gaps before = %q; want %q", got, tt.gaps)
+			}
+		})
+	}
+}
+
+func TestDisplayTextTruncatedGrep(t *testing.T) {
+	oldCfg, oldLogConfig, oldOutput := cfg, logConfig, output
+	defer func() { cfg, logConfig, output = oldCfg, oldLogConfig, oldOutput }()
+
+	var buf bytes.Buffer
+	output = &buf
+	cfg = Config{NoColor: true}
+	logConfig = LogConfig{grep: regexp.MustCompile("needle")}
+
+	lines := make([]string, 30)
+	for i := range lines {
+		lines[i] = "hay"
+	}
+	lines[20] = "a needle"
+	displayTextTruncated(output, strings.Join(lines, "\n"), "", 10)
+
+	expected := strings.Repeat("hay\n", 10) +
+		"... (10 lines)\n" +
+		"a needle\n" +
+		"... (9 more lines)" + colorReset + "\n"
+	if got := buf.String(); got != expected {
+		t.Errorf("displayTextTruncated() = %q; want %q", got, expected)
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+// Stylesheet embedded in HTML exports, modeled after the terminal colors
+const htmlStyle = `
+:root { --bg: #1e1e1e; --fg: #d4d4d4; --gray: #808080; --blue: #569cd6; --green: #6a9955;
+  --cyan: #4ec9b0; --yellow: #dcdcaa; --red: #f44747; --purple: #c586c0; --panel: #252526; }
+body { background: var(--bg); color: var(--fg); font: 14px/1.5 ui-monospace, SFMono-Regular, Menlo, Consolas, monospace;
+  max-width: 960px; margin: 2em auto; padding: 0 1em; }
+header { border-bottom: 1px solid #333; margin-bottom: 1.5em; }
+h1 { font-size: 1.2em; margin: 0 0 .3em; }
+.meta, time, .version, .model { color: var(--gray); }
+.entry { margin: 0 0 1.2em; }
+.head { margin-bottom: .2em; }
+.role { font-weight: bold; margin-right: .5em; }
+.user .role { color: var(--blue); }
+.assistant .role { color: var(--green); }
+.tool .role { color: var(--cyan); }
+.label { color: var(--purple); }
+.badge { display: inline-block; background: var(--panel); border-radius: 3px; padding: 0 .4em; margin-left: .3em; color: var(--gray); }
+.badge.cost { color: var(--yellow); }
+.badge.error { color: var(--red); }
+.body { margin-left: 1.2em; }
+pre { white-space: pre-wrap; word-wrap: break-word; margin: .2em 0; font: inherit; }
+details { background: var(--panel); border-radius: 4px; padding: .2em .6em; margin: .3em 0; }
+details.error { border-left: 3px solid var(--red); }
+details.thinking { color: var(--gray); font-style: italic; }
+summary { cursor: pointer; }
+.tool-name { color: var(--yellow); }
+dl { margin: .3em 0; }
+dt { color: var(--gray); }
+dd { margin: 0 0 .3em 1.2em; }
+ul.todos { list-style: none; padding-left: 0; margin: .3em 0; }
+ul.todos .in_progress { color: var(--yellow); }
+ul.todos .completed { color: var(--gray); text-decoration: line-through; }
+.priority { color: var(--red); font-size: .85em; }
+`
+
+// displayConversationAsHTML writes the conversation as a self-contained HTML document
+func displayConversationAsHTML(entries []*transcript.Entry, tools transcript.ToolIndex) {
+	title := conversationTitle(entries)
+
+	fmt.Fprintf(output, "<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(output, "<title>%s</title>\n<style>%s</style>\n</head>\n<body>\n", html.EscapeString(title), htmlStyle)
+	displayHTMLHeader(title, entries)
+
+	for _, entry := range entries {
+		if !shouldDisplayEntryWithToolInfo(entry.Type, entry, tools) {
+			continue
+		}
+		displayEntryAsHTML(entry, tools)
+	}
+
+	fmt.Fprintf(output, "</body>\n</html>\n")
+}
+
+// conversationTitle picks a title from the session summary or the first prompt
+func conversationTitle(entries []*transcript.Entry) string {
+	for _, entry := range entries {
+		if entry.Type == "summary" && entry.Summary != "" {
+			return entry.Summary
+		}
+	}
+	for _, entry := range entries {
+		if entry.IsPrompt() {
+			if text := strings.TrimSpace(entry.Message.Content.Text()); text != "" {
+				return truncateRunes(strings.SplitN(text, "\n", 2)[0], 80)
+			}
+		}
+	}
+	return "Claude Code session"
+}
+
+// displayHTMLHeader writes the document header with session totals
+func displayHTMLHeader(title string, entries []*transcript.Entry) {
+	stats := collectSessionStats(entries)
+
+	fmt.Fprintf(output, "<header>\n<h1>%s</h1>\n<p class=\"meta\">", html.EscapeString(title))
+	if !stats.Start.IsZero() {
+		fmt.Fprintf(output, "%s &middot; %s &middot; ",
+			stats.Start.Local().Format("2006-01-02 15:04"), stats.End.Sub(stats.Start).Round(time.Second))
+	}
+	fmt.Fprintf(output, "%d prompts &middot; %d tool calls &middot; %s tokens",
+		stats.UserPrompts, stats.totalToolCalls(), formatNumber(stats.Usage.Total()))
+	if cfg.ShowCost {
+		calculateStatsCost(stats)
+		fmt.Fprintf(output, " &middot; $%.4f", stats.Cost)
+	}
+	fmt.Fprintf(output, "</p>\n</header>\n")
+}
+
+// displayEntryAsHTML writes one entry as a section
+func displayEntryAsHTML(entry *transcript.Entry, tools transcript.ToolIndex) {
+	message := entry.Message
+	if message == nil || (entry.Type != "user" && entry.Type != "assistant") {
+		return
+	}
+
+	switch {
+	case entry.Type == "assistant":
+		fmt.Fprintf(output, "<section class=\"entry assistant\">\n<div class=\"head\"><span class=\"role\">ASSISTANT</span>")
+		displayHTMLEntryMeta(entry)
+		if message.Model != "" {
+			fmt.Fprintf(output, " <span class=\"model\">%s</span>", html.EscapeString(message.Model))
+		}
+		displayHTMLUsageBadges(message)
+	case entry.HasToolResult():
+		fmt.Fprintf(output, "<section class=\"entry tool\">\n<div class=\"head\"><span class=\"role\">TOOL</span>")
+		displayHTMLEntryMeta(entry)
+		if toolName := getToolNameFromResult(message, tools); toolName != "" {
+			fmt.Fprintf(output, " <span class=\"tool-name\">%s</span>", html.EscapeString(toolName))
+		}
+	default:
+		fmt.Fprintf(output, "<section class=\"entry user\">\n<div class=\"head\"><span class=\"role\">USER</span>")
+		if text := message.Content.FirstOfType(transcript.BlockText); text != nil &&
+			strings.Contains(text.Text, "<command-name>") {
+			fmt.Fprintf(output, "<span class=\"label\">[COMMAND]</span> ")
+		}
+		displayHTMLEntryMeta(entry)
+	}
+	fmt.Fprintf(output, "</div>\n<div class=\"body\">\n")
+
+	for i := range message.Content {
+		block := &message.Content[i]
+		switch block.Type {
+		case transcript.BlockText:
+			if strings.TrimSpace(block.Text) != "" {
+				fmt.Fprintf(output, "<pre>%s</pre>\n", html.EscapeString(block.Text))
+			}
+		case transcript.BlockToolUse:
+			displayToolUseAsHTML(block)
+		case transcript.BlockToolResult:
+			displayToolResultAsHTML(entry, block, tools)
+		case transcript.BlockThinking, transcript.BlockRedactedThinking:
+			if !logConfig.noThinking {
+				displayThinkingAsHTML(block)
+			}
+		}
+	}
+
+	fmt.Fprintf(output, "</div>\n</section>\n")
+}
+
+// displayHTMLEntryMeta writes the timestamp and version of an entry
+func displayHTMLEntryMeta(entry *transcript.Entry) {
+	if t, ok := entry.Time(); ok {
+		fmt.Fprintf(output, "<time datetime=\"%s\">%s</time>",
+			t.Format(time.RFC3339), t.Local().Format("2006-01-02 15:04:05"))
+	}
+	if entry.Version != "" {
+		fmt.Fprintf(output, " <span class=\"version\">v%s</span>", html.EscapeString(entry.Version))
+	}
+}
+
+// displayHTMLUsageBadges writes token and cost badges for an assistant message
+func displayHTMLUsageBadges(message *transcript.Message) {
+	usage := message.Usage
+	if usage == nil {
+		return
+	}
+	fmt.Fprintf(output, " <span class=\"badge\" title=\"input / output tokens\">↑%s ↓%s</span>",
+		formatNumber(usage.InputTokens), formatNumber(usage.OutputTokens))
+	if usage.CacheReadInputTokens > 0 || usage.CacheCreationInputTokens > 0 {
+		fmt.Fprintf(output, " <span class=\"badge\" title=\"cache read / cache write tokens\">*%s +%s</span>",
+			formatNumber(usage.CacheReadInputTokens), formatNumber(usage.CacheCreationInputTokens))
+	}
+	if cfg.ShowCost {
+		if cost := calculateCost(*usage, message.Model); cost > 0 {
+			fmt.Fprintf(output, " <span class=\"badge cost\">$%.4f</span>", cost)
+		}
+	}
+}
+
+// displayToolUseAsHTML writes a tool call as a collapsible block
+func displayToolUseAsHTML(block *transcript.ContentBlock) {
+	fmt.Fprintf(output, "<details class=\"tool-use\">\n<summary><span class=\"tool-name\">%s</span> %s</summary>\n",
+		html.EscapeString(block.Name), html.EscapeString(toolUseSummary(block)))
+
+	if block.Name == "TodoWrite" {
+		if todos := block.Todos(); len(todos) > 0 {
+			displayTodosAsHTML(todos)
+			fmt.Fprintf(output, "</details>\n")
+			return
+		}
+	}
+
+	input := block.InputFields()
+	keys := make([]string, 0, len(input))
+	for key := range input {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(output, "<dl>\n")
+	for _, key := range keys {
+		fmt.Fprintf(output, "<dt>%s</dt><dd><pre>%s</pre></dd>\n",
+			html.EscapeString(key), html.EscapeString(toolInputValueText(input[key])))
+	}
+	fmt.Fprintf(output, "</dl>\n</details>\n")
+}
+
+// displayToolResultAsHTML writes a tool result as a collapsible block; errors start expanded
+func displayToolResultAsHTML(entry *transcript.Entry, result *transcript.ContentBlock, tools transcript.ToolIndex) {
+	class, open, status := "result", "", "output"
+	if result.IsError {
+		class, open, status = "result error", " open", "<span class=\"badge error\">ERROR</span>"
+	}
+	text := result.Content.Text()
+	if lines := strings.Count(text, "\n") + 1; text != "" && !result.IsError {
+		status = fmt.Sprintf("output (%d line%s)", lines, pluralize(lines))
+	}
+
+	if tools.Name(result.ToolUseID) == "TodoWrite" && !result.IsError {
+		if todos, ok := transcript.TodosFromResult(entry.ToolUseResultMap()); ok {
+			fmt.Fprintf(output, "<details class=\"%s\">\n<summary>todos</summary>\n", class)
+			displayTodosAsHTML(todos)
+			fmt.Fprintf(output, "</details>\n")
+			return
+		}
+	}
+
+	fmt.Fprintf(output, "<details class=\"%s\"%s>\n<summary>%s</summary>\n", class, open, status)
+
+	if text == "" {
+		fmt.Fprintf(output, "<pre class=\"meta\">(No content)</pre>\n")
+	} else {
+		fmt.Fprintf(output, "<pre>%s</pre>\n", html.EscapeString(text))
+	}
+	fmt.Fprintf(output, "</details>\n")
+}
+
+// displayTodosAsHTML writes a todo list as a checklist
+func displayTodosAsHTML(todos []transcript.Todo) {
+	fmt.Fprintf(output, "<ul class=\"todos\">\n")
+	for _, todo := range todos {
+		checked := ""
+		if todo.Status == "completed" {
+			checked = " checked"
+		}
+		fmt.Fprintf(output, "<li class=\"%s\"><input type=\"checkbox\" disabled%s> %s",
+			html.EscapeString(todo.Status), checked, html.EscapeString(todo.Content))
+		if todo.Priority == "high" {
+			fmt.Fprintf(output, " <span class=\"priority\">HIGH</span>")
+		}
+		fmt.Fprintf(output, "</li>\n")
+	}
+	fmt.Fprintf(output, "</ul>\n")
+}
+
+// toolUseSummary returns a one-line description of a tool call, such as
+// the Bash command or the file path
+func toolUseSummary(block *transcript.ContentBlock) string {
+	input := block.InputFields()
+	for _, key := range []string{"command", "file_path", "path", "pattern", "url", "query", "description"} {
+		if value, ok := input[key].(string); ok && value != "" {
+			line := strings.TrimSpace(strings.SplitN(value, "\n", 2)[0])
+			return truncateRunes(line, 80)
+		}
+	}
+	if block.Name == "TodoWrite" {
+		if todos := block.Todos(); len(todos) > 0 {
+			return fmt.Sprintf("%d item%s", len(todos), pluralize(len(todos)))
+		}
+	}
+	return ""
+}
+
+// toolInputValueText formats a tool input value in full
+func toolInputValueText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return "null"
+	}
+	if data, err := json.MarshalIndent(value, "", "  "); err == nil {
+		return string(data)
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+func TestDisplayConversationAsHTML(t *testing.T) {
+	origCfg, origOutput := cfg, output
+	defer func() {
+		cfg, output = origCfg, origOutput
+	}()
+	cfg = Config{OutputFormat: "html"}
+
+	longOutput := strings.Repeat("line\n", 30) + "last line"
+	input := strings.Join([]string{
+		`{"type":"user","uuid":"u1","timestamp":"2025-06-22T09:00:00Z","message":{"role":"user","content":"Fix <script> & tests"}}`,
+		`{"type":"assistant","uuid":"a1","parentUuid":"u1","timestamp":"2025-06-22T09:00:05Z","message":{"id":"msg_1","model":"claude-sonnet-4","content":[{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"go test ./..."}}],"usage":{"input_tokens":1200,"output_tokens":5}}}`,
+		`{"type":"user","uuid":"u2","parentUuid":"a1","timestamp":"2025-06-22T09:00:10Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":` + quoteJSON(longOutput) + `}]}}`,
+		`{"type":"assistant","uuid":"a2","parentUuid":"u2","timestamp":"2025-06-22T09:00:15Z","message":{"id":"msg_2","model":"claude-sonnet-4","content":[{"type":"tool_use","id":"t2","name":"TodoWrite","input":{"todos":[{"id":"1","content":"Fix build","status":"completed","priority":"high"},{"id":"2","content":"Run tests","status":"pending","priority":"low"}]}}]}}`,
+	}, "\n")
+	entries, err := transcript.ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	output = &buf
+	displayConversationAsHTML(entries, transcript.NewToolIndex(entries))
+	got := buf.String()
+
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<title>Fix &lt;script&gt; &amp; tests</title>",
+		"<pre>Fix &lt;script&gt; &amp; tests</pre>",
+		`<summary><span class="tool-name">Bash</span> go test ./...</summary>`,
+		"last line",
+		"↑1,200 ↓5",
+		`<time datetime="2025-06-22T09:00:05Z">`,
+		`<li class="completed"><input type="checkbox" disabled checked> Fix build`,
+		`<li class="pending"><input type="checkbox" disabled> Run tests`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	if strings.Contains(got, "<script>") || strings.Contains(got, "\x1b[") {
+		t.Errorf("output contains unescaped text or color codes:\n%s", got)
+	}
+}
+
+func quoteJSON(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
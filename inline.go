@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+// Tool result entries shown under their tool_use with --inline-results
+// instead of as TOOL entries of their own
+var inlinedResults map[*transcript.Entry]bool
+
+// inlineResults marks the tool result entries whose calls are all displayed
+// among entries, and returns the entries without them
+func inlineResults(entries []*transcript.Entry, tools transcript.ToolIndex) []*transcript.Entry {
+	displayed := make(map[*transcript.Entry]bool)
+	for _, entry := range entries {
+		if entry.Type == "assistant" && shouldDisplayEntryWithToolInfo(entry.Type, entry, tools) {
+			displayed[entry] = true
+		}
+	}
+
+	inlinedResults = make(map[*transcript.Entry]bool)
+	var rest []*transcript.Entry
+	for _, entry := range entries {
+		if isInlineable(entry, tools, displayed) {
+			inlinedResults[entry] = true
+			continue
+		}
+		rest = append(rest, entry)
+	}
+	return rest
+}
+
+// isInlineable reports whether an entry holds only tool results whose
+// calls are in displayed entries
+func isInlineable(entry *transcript.Entry, tools transcript.ToolIndex, displayed map[*transcript.Entry]bool) bool {
+	if entry.Type != "user" || entry.Message == nil || len(entry.Message.Content) == 0 {
+		return false
+	}
+	for _, block := range entry.Message.Content {
+		if block.Type != transcript.BlockToolResult {
+			return false
+		}
+		call := tools[block.ToolUseID]
+		if call == nil || !displayed[call.Entry] {
+			return false
+		}
+	}
+	return true
+}
+
+// inlinedResult returns the result of a tool call if it is shown under the call
+func inlinedResult(tool *transcript.ContentBlock, tools transcript.ToolIndex) (*transcript.Entry, *transcript.ContentBlock) {
+	result, block := tools.Result(tool.ID)
+	if result == nil || !inlinedResults[result] {
+		return nil, nil
+	}
+	return result, block
+}
+
+// displayInlineResult prints the result of a tool call under it,
+// with the time the call took
+func displayInlineResult(w io.Writer, tool *transcript.ContentBlock, indent string, tools transcript.ToolIndex) {
+	result, block := inlinedResult(tool, tools)
+	if result == nil {
+		return
+	}
+
+	call := tools[tool.ID]
+	fmt.Fprintf(w, "%s%s[Result]%s", indent, color(colorCyan), color(colorReset))
+	if latency, ok := toolLatency(call.Entry, result); ok {
+		fmt.Fprintf(w, " %s(%s)%s", color(colorGray), formatElapsed(latency), color(colorReset))
+	}
+	fmt.Fprintln(w)
+	displayToolResultFull(w, block, indent+"  ", tool.Name, result.ToolUseResultMap(), tool)
+}
+
+// displayInlineResultsCompact prints one line per inlined result of an
+// assistant message, below its compact summary
+func displayInlineResultsCompact(w io.Writer, message *transcript.Message, tools transcript.ToolIndex) {
+	for i := range message.Content {
+		tool := &message.Content[i]
+		if tool.Type != transcript.BlockToolUse {
+			continue
+		}
+		result, _ := inlinedResult(tool, tools)
+		if result == nil {
+			continue
+		}
+
+		fmt.Fprintf(w, "%11s%s↳ %s", "", color(colorCyan), tool.Name)
+		if latency, ok := toolLatency(tools[tool.ID].Entry, result); ok {
+			fmt.Fprintf(w, " %s", formatElapsed(latency))
+		}
+		fmt.Fprintf(w, "%s - ", color(colorReset))
+		displayToolResultCompact(w, result.Message, tool.Name, tool)
+	}
+}
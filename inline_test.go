@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+func TestInlineResults(t *testing.T) {
+	origCfg, origOutput, origInlined := cfg, output, inlinedResults
+	defer func() {
+		cfg, output, inlinedResults = origCfg, origOutput, origInlined
+	}()
+	cfg = Config{OutputFormat: "text", NoColor: true}
+
+	// Two calls run in parallel; their results arrive in the opposite order
+	input := strings.Join([]string{
+		`{"type":"assistant","uuid":"a1","timestamp":"2025-06-22T09:00:00Z","message":{"content":[{"type":"tool_use","id":"t1","name":"Grep","input":{"pattern":"first"}},{"type":"tool_use","id":"t2","name":"Glob","input":{"pattern":"second"}}]}}`,
+		`{"type":"user","uuid":"r2","timestamp":"2025-06-22T09:00:00.400Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t2","content":"second result"}]}}`,
+		`{"type":"user","uuid":"r1","timestamp":"2025-06-22T09:00:02Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"first result"}]}}`,
+		`{"type":"user","uuid":"r3","timestamp":"2025-06-22T09:00:03Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"missing","content":"orphan result"}]}}`,
+	}, "\n")
+	entries, err := transcript.ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	tools := transcript.NewToolIndex(entries)
+
+	rest := inlineResults(entries, tools)
+	var uuids []string
+	for _, entry := range rest {
+		uuids = append(uuids, entry.UUID)
+	}
+	if got := strings.Join(uuids, " "); got != "a1 r3" {
+		t.Fatalf("inlineResults() kept %q; want %q", got, "a1 r3")
+	}
+
+	var buf bytes.Buffer
+	output = &buf
+	for _, entry := range rest {
+		displayEntryWithToolInfo(entry, tools)
+	}
+	got := buf.String()
+
+	// Each result follows its own call, with its latency
+	order := []string{"Grep", "[Result] (2.0s)", "first result", "Glob", "[Result] (400ms)", "second result", "TOOL", "orphan result"}
+	pos := 0
+	for _, want := range order {
+		i := strings.Index(got[pos:], want)
+		if i < 0 {
+			t.Fatalf("output missing %q after position %d:\n%s", want, pos, got)
+		}
+		pos += i + len(want)
+	}
+}
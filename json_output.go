@@ -3,12 +3,49 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+
+	"github.com/Sixeight/ccl/transcript"
 )
 
-// Display entry as JSON - outputs the original JSON without modification
-func displayEntryAsJSON(entry map[string]interface{}, toolUseMap map[string]string) {
-	// For JSON output, output the original entry as-is without any processing
-	if jsonBytes, err := json.Marshal(entry); err == nil {
-		fmt.Println(string(jsonBytes))
+// Display entry as JSON - outputs the original JSON without modification,
+// except for thinking blocks removed by --no-thinking and the cost added by --cost
+func displayEntryAsJSON(w io.Writer, entry *transcript.Entry) {
+	// For JSON w, output the original line as-is without any processing
+	raw := entry.Raw
+	if len(raw) == 0 {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return
+		}
+		raw = data
+	}
+	if logConfig.noThinking {
+		raw, _ = withoutThinking(raw)
+	}
+	if cfg.ShowCost {
+		raw = withCost(raw, entry.Message)
+	}
+	fmt.Fprintln(w, string(raw))
+}
+
+// withCost adds the cost of a message with usage and the price entry used,
+// as cost_usd and price_key; both are null for an unpriced model
+func withCost(raw json.RawMessage, message *transcript.Message) json.RawMessage {
+	if message == nil || message.Usage == nil {
+		return raw
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return raw
+	}
+	entry["cost_usd"], entry["price_key"] = nil, nil
+	if key := priceKeyForModel(message.Model); key != "" {
+		entry["cost_usd"], entry["price_key"] = calculateCost(*message.Usage, message.Model), key
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return raw
 	}
+	return data
 }
@@ -1,14 +1,19 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"syscall"
 	"time"
+
+	"github.com/Sixeight/ccl/projects"
+	"github.com/Sixeight/ccl/transcript"
 )
 
 const version = "0.0.1"
@@ -31,6 +36,9 @@ type Config struct {
 	StatsCurrent  bool
 	ShowInfoAll   bool
 	Compact       bool
+	PricingFile   string
+	Verbose       bool
+	ShowBranches  bool
 }
 
 var cfg Config
@@ -43,6 +51,28 @@ func init() {
 // LogConfig holds flags specific to the log command
 type LogConfig struct {
 	jsonFlag bool
+	since    string
+	until    string
+	first    int
+	last     int
+	fromUUID string
+	toUUID   string
+	rng      entryRange // Parsed from the flags above
+
+	grepText    string
+	grepContext int
+	grep        *regexp.Regexp // Compiled from grepText
+
+	whereText string
+	where     whereExpr // Compiled from whereText
+
+	errors        bool
+	inlineResults bool
+	thinking      bool // Show thinking blocks in full
+	noThinking    bool // Hide thinking blocks
+	followProject bool
+	exec          string // Command whose output is followed
+	status        bool   // Show a live status line while following
 }
 
 var logConfig LogConfig
@@ -52,15 +82,34 @@ func setupLogFlags(logCmd *flag.FlagSet) {
 	logCmd.StringVar(&cfg.ProjectPath, "p", "", "path to Claude Code project file")
 	logCmd.BoolVar(&cfg.NoColor, "no-color", false, "disable color output")
 	logCmd.BoolVar(&cfg.Compact, "compact", false, "compact output mode")
-	logCmd.StringVar(&cfg.Role, "role", "", "filter by role (user,assistant,tool)")
+	logCmd.StringVar(&cfg.Role, "role", "", "filter by role (user,assistant,tool,thinking)")
 	logCmd.StringVar(&cfg.ToolFilter, "tool", "", "filter by tool name (supports glob: Bash,*Edit,Todo*)")
 	logCmd.BoolVar(&cfg.ShowAllTools, "tools", false, "show all tool calls (equivalent to --tool '*')")
 	logCmd.StringVar(&cfg.ToolExclude, "tool-exclude", "", "exclude tools by name (supports glob)")
-	logCmd.BoolVar(&cfg.ShowCost, "cost", false, "show token costs (fetches latest pricing)")
+	logCmd.StringVar(&cfg.PricingFile, "pricing", "", "price table override file (LiteLLM schema)")
+	logCmd.BoolVar(&cfg.ShowCost, "cost", false, "show token costs")
+	logCmd.BoolVar(&cfg.Verbose, "verbose", false, "show additional details (e.g. the price table entry used with --cost)")
 	logCmd.BoolVar(&cfg.ShowTiming, "timing", false, "show timing information between messages")
-	logCmd.StringVar(&cfg.OutputFormat, "format", "text", "output format (text, json)")
+	logCmd.StringVar(&cfg.OutputFormat, "format", "text", "output format (text, json, html, markdown)")
 	logCmd.BoolVar(&logConfig.jsonFlag, "json", false, "shortcut for --format json")
 	logCmd.BoolVar(&cfg.Follow, "f", false, "follow mode - continuously monitor for new entries (like tail -f)")
+	logCmd.BoolVar(&logConfig.followProject, "follow-project", false, "follow all sessions of the project, switching to new ones (e.g. after /clear)")
+	logCmd.StringVar(&logConfig.exec, "exec", "", "follow the output of a command (e.g. 'ssh host tail -f ~/.claude/projects/.../session.jsonl')")
+	logCmd.BoolVar(&logConfig.status, "status", false, "show a live status line while following: time, turns, tokens, cost, current todo and running tool")
+	logCmd.BoolVar(&cfg.ShowBranches, "branches", false, "show abandoned branches (rewinds, edited prompts) as an indented tree")
+	logCmd.StringVar(&logConfig.since, "since", "", "show entries at or after a time (RFC3339, 2006-01-02, or ago like 2h, 3d)")
+	logCmd.StringVar(&logConfig.until, "until", "", "show entries at or before a time (same forms as --since; a date includes that day)")
+	logCmd.IntVar(&logConfig.first, "first", 0, "show only the first N turns (a turn starts at each user prompt)")
+	logCmd.IntVar(&logConfig.last, "last", 0, "show only the last N turns")
+	logCmd.StringVar(&logConfig.fromUUID, "from-uuid", "", "show entries starting at the entry with this UUID")
+	logCmd.StringVar(&logConfig.toUUID, "to-uuid", "", "show entries up to and including the entry with this UUID")
+	logCmd.StringVar(&logConfig.grepText, "grep", "", "show only entries whose text, tool input or tool result matches a regexp")
+	logCmd.IntVar(&logConfig.grepContext, "grep-context", 0, "show N entries before and after each --grep match")
+	logCmd.BoolVar(&logConfig.thinking, "thinking", false, "show thinking blocks in full (by default they are truncated)")
+	logCmd.BoolVar(&logConfig.noThinking, "no-thinking", false, "hide thinking blocks")
+	logCmd.BoolVar(&logConfig.inlineResults, "inline-results", false, "show each tool result under its tool call, with the time it took")
+	logCmd.BoolVar(&logConfig.errors, "errors", false, "show only failed tool calls, each with its error, and counts per tool")
+	logCmd.StringVar(&logConfig.whereText, "where", "", "show only entries matching an expression (e.g. 'role=assistant and tool~\"*Edit\" and not error')")
 	logCmd.BoolVar(&cfg.StatsProjects, "projects", false, "list project file paths only (for piping)")
 	logCmd.BoolVar(&cfg.StatsCurrent, "current", false, "list current directory's project files only")
 }
@@ -79,6 +128,14 @@ func printUsage() {
 	fmt.Fprintf(os.Stderr, "Commands:\n")
 	fmt.Fprintf(os.Stderr, "  log      Display project logs (default)\n")
 	fmt.Fprintf(os.Stderr, "  status   Show project status and information\n")
+	fmt.Fprintf(os.Stderr, "  stats    Summarize tool usage, tokens and cost of a session\n")
+	fmt.Fprintf(os.Stderr, "  usage    Report token usage and cost by day, project and model\n")
+	fmt.Fprintf(os.Stderr, "  pricing  Update the cached model price table\n")
+	fmt.Fprintf(os.Stderr, "  search   Search all sessions for text, commands and tool output\n")
+	fmt.Fprintf(os.Stderr, "  browse   Browse sessions and messages interactively\n")
+	fmt.Fprintf(os.Stderr, "  files    List files changed by a session and reconstruct their content\n")
+	fmt.Fprintf(os.Stderr, "  patch    Print the changes of a session as a patch for git apply\n")
+	fmt.Fprintf(os.Stderr, "  watch    Show new entries of all running sessions in one stream\n")
 	fmt.Fprintf(os.Stderr, "  version  Show version information\n")
 	fmt.Fprintf(os.Stderr, "  help     Show this help message\n\n")
 	fmt.Fprintf(os.Stderr, "Examples:\n")
@@ -87,10 +144,14 @@ func printUsage() {
 	fmt.Fprintf(os.Stderr, "  ccl log\n\n")
 	fmt.Fprintf(os.Stderr, "  # Show project status\n")
 	fmt.Fprintf(os.Stderr, "  ccl status\n\n")
+	fmt.Fprintf(os.Stderr, "  # Summarize the latest session\n")
+	fmt.Fprintf(os.Stderr, "  ccl stats\n\n")
 	fmt.Fprintf(os.Stderr, "  # Show all tool calls\n")
 	fmt.Fprintf(os.Stderr, "  ccl log --tools\n\n")
 	fmt.Fprintf(os.Stderr, "  # Follow mode (like tail -f)\n")
 	fmt.Fprintf(os.Stderr, "  ccl log -f\n\n")
+	fmt.Fprintf(os.Stderr, "  # Follow the project, switching to new sessions\n")
+	fmt.Fprintf(os.Stderr, "  ccl log --follow-project\n\n")
 	fmt.Fprintf(os.Stderr, "Use 'ccl [command] --help' for more information about a command.\n")
 }
 
@@ -117,6 +178,22 @@ func main() {
 		runLogCommand(os.Args[2:])
 	case "status":
 		runStatusCommand(os.Args[2:])
+	case "stats":
+		runStatsCommand(os.Args[2:])
+	case "usage":
+		runUsageCommand(os.Args[2:])
+	case "pricing":
+		runPricingCommand(os.Args[2:])
+	case "search":
+		runSearchCommand(os.Args[2:])
+	case "browse":
+		runBrowseCommand(os.Args[2:])
+	case "files":
+		runFilesCommand(os.Args[2:])
+	case "patch":
+		runPatchCommand(os.Args[2:])
+	case "watch":
+		runWatchCommand(os.Args[2:])
 	case "version":
 		fmt.Printf("ccl version %s\n", version)
 	case "help":
@@ -128,10 +205,11 @@ func main() {
 	}
 }
 
-// fileExists checks if a file exists
+// fileExists checks if a regular file exists, so that a directory
+// named like a subcommand does not shadow it
 func fileExists(path string) bool {
-	_, err := os.Stat(path)
-	return err == nil
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
 }
 
 // runLogCommand runs the log subcommand
@@ -155,6 +233,39 @@ func runLogCommand(args []string) {
 		cfg.OutputFormat = "json"
 	}
 
+	rng, err := parseEntryRange(logConfig.since, logConfig.until, logConfig.first, logConfig.last,
+		logConfig.fromUUID, logConfig.toUUID, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	logConfig.rng = rng
+
+	if logConfig.grepText != "" {
+		if logConfig.grep, err = regexp.Compile(logConfig.grepText); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --grep: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if logConfig.grepContext < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --grep-context must not be negative\n")
+		os.Exit(1)
+	}
+	if logConfig.thinking && logConfig.noThinking {
+		fmt.Fprintf(os.Stderr, "Error: --thinking and --no-thinking cannot be used together\n")
+		os.Exit(1)
+	}
+	if logConfig.status && (!cfg.Follow && logConfig.exec == "" || logConfig.followProject) {
+		fmt.Fprintf(os.Stderr, "Error: --status only works when following a single session (-f or --exec)\n")
+		os.Exit(1)
+	}
+	if logConfig.whereText != "" {
+		if logConfig.where, err = compileWhere(logConfig.whereText); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --where: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	// Handle project listing flags first
 	if cfg.StatsProjects {
 		listProjectFiles()
@@ -171,22 +282,41 @@ func runLogCommand(args []string) {
 		cfg.ToolFilter = "*"
 	}
 
-	// Disable colors for JSON output
-	if cfg.OutputFormat == "json" {
+	// Disable colors for JSON and document output
+	if cfg.OutputFormat != "text" {
 		cfg.NoColor = true
 	}
 
-	// Fetch pricing data if cost flag is set
-	if cfg.ShowCost && cfg.OutputFormat == "text" {
-		if err := fetchModelPricing(); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Failed to fetch pricing data: %v\n", err)
-			// Continue without cost display
-			cfg.ShowCost = false
+	// Load pricing data if cost flag is set
+	if (cfg.ShowCost || logConfig.status) && cfg.OutputFormat != "json" {
+		if err := loadModelPricing(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
 		}
 	}
 
+	// Follow the output of a command instead of a file
+	if logConfig.exec != "" {
+		if err := processExec(logConfig.exec); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		return
+	}
+
+	// Follow a whole project directory instead of a single input
+	if logConfig.followProject {
+		dir, first, err := projectToFollow(logCmd)
+		if err == nil {
+			err = processFollowProject(dir, first)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		return
+	}
+
 	// Get input reader
-	reader, cleanup, err := getInputReaderForLog(logCmd)
+	reader, cleanup, err := getInputReader(logCmd)
 	if cleanup != nil {
 		defer cleanup()
 	}
@@ -244,8 +374,8 @@ func runStatusCommand(args []string) {
 	}
 }
 
-// Get input source for log command
-func getInputReaderForLog(logCmd *flag.FlagSet) (io.Reader, func(), error) {
+// Get input source for commands reading a single transcript
+func getInputReader(cmd *flag.FlagSet) (io.Reader, func(), error) {
 	// Check if stdin has data (pipe or redirect)
 	stat, _ := os.Stdin.Stat()
 	if (stat.Mode() & os.ModeCharDevice) == 0 {
@@ -263,7 +393,7 @@ func getInputReaderForLog(logCmd *flag.FlagSet) (io.Reader, func(), error) {
 	}
 
 	// Check for file path from command line argument
-	args := logCmd.Args()
+	args := cmd.Args()
 	if len(args) > 0 {
 		file, err := os.Open(args[0])
 		if err != nil {
@@ -273,10 +403,10 @@ func getInputReaderForLog(logCmd *flag.FlagSet) (io.Reader, func(), error) {
 	}
 
 	// Try to find project file for current directory
-	projectFile := findProjectFile()
+	cwd, _ := os.Getwd()
+	projectFile := projects.LatestSession(cwd)
 	if projectFile == "" {
-		configDir := getClaudeConfigDir()
-		return nil, nil, fmt.Errorf("no input provided and no project file found for current directory in %s/projects/", configDir)
+		return nil, nil, fmt.Errorf("no input provided and no project file found for current directory in %s/", projects.Root())
 	}
 
 	file, err := os.Open(projectFile)
@@ -286,25 +416,69 @@ func getInputReaderForLog(logCmd *flag.FlagSet) (io.Reader, func(), error) {
 	return file, func() { _ = file.Close() }, nil
 }
 
+// Get the project directory to follow and the session to show first:
+// the directory of a given session file, a given project directory,
+// or the project directory of the current directory
+func projectToFollow(cmd *flag.FlagSet) (string, string, error) {
+	path := cfg.ProjectPath
+	if path == "" && len(cmd.Args()) > 0 {
+		path = cmd.Args()[0]
+	}
+	if path != "" {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", "", fmt.Errorf("opening file: %w", err)
+		}
+		if info.IsDir() {
+			return path, projects.LatestSessionIn(path), nil
+		}
+		return filepath.Dir(path), path, nil
+	}
+
+	cwd, _ := os.Getwd()
+	dir := projects.Dir(cwd)
+	if info, err := os.Stat(dir); dir == "" || err != nil || !info.IsDir() {
+		return "", "", fmt.Errorf("no project directory found for current directory in %s/", projects.Root())
+	}
+	return dir, projects.LatestSession(cwd), nil
+}
+
 // Process the conversation from reader
 func processConversation(reader io.Reader) error {
 	// Determine processing mode
 	file, isFile := reader.(*os.File)
 	isStdin := isFile && file == os.Stdin
 
-	// Follow mode only works with files (not stdin)
+	// Follow mode: files are tailed, while piped input and named pipes
+	// are shown as their entries arrive
 	if cfg.Follow {
-		if !isFile || isStdin {
-			return fmt.Errorf("follow mode (-f) only works with file input, not stdin")
+		if err := checkLiveOutput("follow mode (-f)"); err != nil {
+			return err
+		}
+		switch {
+		case isFile && !isStdin && !isNamedPipe(file):
+			return processFollowMode(file)
+		case logConfig.rng.last > 0:
+			return fmt.Errorf("--last is not supported when following piped input")
+		case isFile && !isStdin:
+			return followStream(file, reopenPipe(file))
+		default:
+			return followStream(reader, nil)
 		}
-		return processFollowMode(file)
+	}
+
+	// Document formats need the whole conversation at once,
+	// and the error summary is printed after it
+	if isDocumentFormat() || logConfig.errors {
+		return processBuffered(reader)
 	}
 
 	// Check if we should use streaming mode
 	if isStdin {
 		stat, _ := os.Stdin.Stat()
 		isStreaming := (stat.Mode() & os.ModeCharDevice) == 0
-		if isStreaming {
+		// Results can only be shown under their calls once all are read
+		if isStreaming && !logConfig.inlineResults {
 			return processStreaming(reader)
 		}
 	}
@@ -313,186 +487,191 @@ func processConversation(reader io.Reader) error {
 	return processBuffered(reader)
 }
 
-// Process follow mode - continuously monitor file for new entries
-func processFollowMode(file *os.File) error {
-	// Tool maps that persist across all entries
-	toolUseMap := make(map[string]string)
-	toolInputMap := make(map[string]map[string]interface{})
-
-	// First pass: collect tool information from existing content
-	scanner := bufio.NewScanner(file)
-	const maxScanTokenSize = 1024 * 1024 * 10 // 10MB
-	buf := make([]byte, maxScanTokenSize)
-	scanner.Buffer(buf, maxScanTokenSize)
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		var entry map[string]interface{}
-		if err := json.Unmarshal([]byte(line), &entry); err != nil {
-			continue
-		}
-		// Collect tool use information
-		if msgType, _ := entry["type"].(string); msgType == "assistant" {
-			collectToolUseInfo(entry, toolUseMap, toolInputMap)
-		}
+// checkLiveOutput rejects output options that need the whole conversation
+// for a source (named by option) shown as it is written
+func checkLiveOutput(option string) error {
+	if isDocumentFormat() {
+		return fmt.Errorf("%s is not supported with --format %s", option, cfg.OutputFormat)
 	}
-
-	// Reset to beginning for display pass
-	_, err := file.Seek(0, 0)
-	if err != nil {
-		return fmt.Errorf("seeking to beginning: %w", err)
+	if logConfig.errors {
+		return fmt.Errorf("%s is not supported with --errors", option)
 	}
+	return nil
+}
 
-	// Display all existing content
-	if fileErr := processBuffered(file); fileErr != nil {
-		return fileErr
-	}
+// isDocumentFormat reports whether the output format renders a complete document
+func isDocumentFormat() bool {
+	return cfg.OutputFormat == "html" || cfg.OutputFormat == "markdown"
+}
 
-	// Get current position (end of file)
-	currentPos, err := file.Seek(0, io.SeekCurrent)
+// Process follow mode - continuously monitor file for new entries
+func processFollowMode(file *os.File) error {
+	session, err := followSession(file)
 	if err != nil {
-		return fmt.Errorf("getting current position: %w", err)
+		return err
 	}
+	session.tail.watch()
+	defer session.tail.Close()
+	session.showStatus()
+	defer session.stopStatus()
+
+	// Stop cleanly on Ctrl-C
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
 
-	// Continuously monitor for new content
+	// Display new entries as their lines are completed
 	for {
-		// Check current file size
-		stat, err := file.Stat()
+		entries, err := session.newEntries()
 		if err != nil {
-			return fmt.Errorf("getting file stats: %w", err)
+			return err
 		}
+		for _, entry := range entries {
+			session.display(entry)
+		}
+		session.updateStatus()
 
-		if stat.Size() > currentPos {
-			// New content available
-			_, err := file.Seek(currentPos, 0)
-			if err != nil {
-				return fmt.Errorf("seeking to position: %w", err)
-			}
-
-			// Process new lines
-			scanner := bufio.NewScanner(file)
-			const maxScanTokenSize = 1024 * 1024 * 10 // 10MB
-			buf := make([]byte, maxScanTokenSize)
-			scanner.Buffer(buf, maxScanTokenSize)
-
-			for scanner.Scan() {
-				line := scanner.Text()
+		if !session.tail.wait(stop) {
+			session.displayTotals()
+			return nil
+		}
+	}
+}
 
-				var entry map[string]interface{}
-				if err := json.Unmarshal([]byte(line), &entry); err != nil {
-					continue // Skip malformed lines
-				}
+// Follow all sessions of a project directory, switching to new ones
+func processFollowProject(dir, first string) error {
+	if err := checkLiveOutput("--follow-project"); err != nil {
+		return err
+	}
 
-				// Collect tool use information
-				if msgType, _ := entry["type"].(string); msgType == "assistant" {
-					collectToolUseInfo(entry, toolUseMap, toolInputMap)
-				}
+	follower, err := newProjectFollower(dir, first)
+	if err != nil {
+		return err
+	}
+	defer follower.Close()
+	return follower.run()
+}
 
-				// Display immediately
-				displayEntryWithToolInfo(entry, toolUseMap, toolInputMap)
-			}
+// Process streaming input
+func processStreaming(r io.Reader) error {
+	reader := transcript.NewReader(r)
+	renderer := newLiveRenderer()
 
-			if err := scanner.Err(); err != nil {
-				return err
-			}
+	// The last turns are only known at the end of the stream
+	var lastTurns *turnBuffer
+	if logConfig.rng.last > 0 {
+		lastTurns = &turnBuffer{n: logConfig.rng.last}
+	}
 
-			// Update current position
-			currentPos = stat.Size()
+	for reader.Next() {
+		entry := reader.Entry()
+		if lastTurns == nil {
+			renderer.render(entry)
+			continue
+		}
+		renderer.collect(entry)
+		if renderer.rng.allow(entry) {
+			lastTurns.add(entry)
 		}
+	}
 
-		// Sleep for a short interval before checking again
-		time.Sleep(100 * time.Millisecond)
+	if lastTurns != nil {
+		for _, entry := range lastTurns.entries {
+			displayEntryWithGrep(entry, renderer.tools, renderer.grep)
+		}
+	}
+	if reader.Err() == nil {
+		if err := renderer.rng.unknownUUID(); err != nil {
+			return err
+		}
 	}
+	renderer.displayTotals()
+	return reader.Err()
 }
 
-// Process streaming input
-func processStreaming(reader io.Reader) error {
-	scanner := bufio.NewScanner(reader)
-	const maxScanTokenSize = 1024 * 1024 * 10 // 10MB
-	buf := make([]byte, maxScanTokenSize)
-	scanner.Buffer(buf, maxScanTokenSize)
+// checkRangeUUIDs rejects a --from-uuid or --to-uuid missing from the entries.
+// A followed file may still gain them, so follow mode is not checked.
+func checkRangeUUIDs(entries []*transcript.Entry) error {
+	if cfg.Follow {
+		return nil
+	}
+	return logConfig.rng.checkUUIDs(entries)
+}
 
-	toolUseMap := make(map[string]string)                   // toolUseID -> toolName
-	toolInputMap := make(map[string]map[string]interface{}) // toolUseID -> input data
+// Process buffered input
+func processBuffered(r io.Reader) error {
+	reader := transcript.NewReader(r)
 
-	for scanner.Scan() {
-		line := scanner.Text()
+	var entries []*transcript.Entry
+	tools := make(transcript.ToolIndex) // toolUseID -> tool call
 
-		var entry map[string]interface{}
-		if err := json.Unmarshal([]byte(line), &entry); err != nil {
-			continue // Skip malformed lines
-		}
+	// First pass: collect all entries and build tool index
+	for reader.Next() {
+		entry := reader.Entry()
 
-		// Collect tool use information for future reference
-		if msgType, _ := entry["type"].(string); msgType == "assistant" {
-			collectToolUseInfo(entry, toolUseMap, toolInputMap)
-		}
+		// Collect tool use information
+		tools.Collect(entry)
 
-		// Display immediately
-		displayEntryWithToolInfo(entry, toolUseMap, toolInputMap)
+		entries = append(entries, entry)
 	}
 
-	return scanner.Err()
-}
+	if err := reader.Err(); err != nil {
+		return err
+	}
 
-// Process buffered input
-func processBuffered(reader io.Reader) error {
-	scanner := bufio.NewScanner(reader)
-	const maxScanTokenSize = 1024 * 1024 * 10 // 10MB
-	buf := make([]byte, maxScanTokenSize)
-	scanner.Buffer(buf, maxScanTokenSize)
-
-	var entries []map[string]interface{}
-	toolUseMap := make(map[string]string)                   // toolUseID -> toolName
-	toolInputMap := make(map[string]map[string]interface{}) // toolUseID -> input data
-
-	// First pass: collect all entries and build tool name map
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		var entry map[string]interface{}
-		if err := json.Unmarshal([]byte(line), &entry); err != nil {
-			continue // Skip malformed lines
+	// Rebuild the conversation tree so rewound branches don't interleave
+	tree := transcript.BuildTree(entries)
+	if cfg.ShowBranches && cfg.OutputFormat == "text" && !logConfig.errors {
+		if err := checkRangeUUIDs(entries); err != nil {
+			return err
+		}
+		var visible map[*transcript.Entry]bool
+		if !logConfig.rng.isZero() || logConfig.grep != nil {
+			visible = make(map[*transcript.Entry]bool)
+			grep := newGrepFilter(logConfig.grep, logConfig.grepContext)
+			for _, entry := range grep.filter(logConfig.rng.apply(entries), tools) {
+				visible[entry] = true
+			}
 		}
+		displayBranches(entries, tree, tools, visible)
+		return nil
+	}
 
-		// Collect tool use information
-		if msgType, _ := entry["type"].(string); msgType == "assistant" {
-			collectToolUseInfo(entry, toolUseMap, toolInputMap)
+	if !cfg.ShowBranches {
+		active := tree.ActiveEntries(entries)
+		if hidden := len(entries) - len(active); hidden > 0 && cfg.OutputFormat == "text" {
+			fmt.Fprintf(os.Stderr, "Note: %d entr%s on abandoned branches hidden (use --branches to show)\n",
+				hidden, pluralizeEntry(hidden))
 		}
+		entries = active
+	}
+	if err := checkRangeUUIDs(entries); err != nil {
+		return err
+	}
+	entries = logConfig.rng.apply(entries)
+	grep := newGrepFilter(logConfig.grep, logConfig.grepContext)
 
-		entries = append(entries, entry)
+	if logConfig.errors {
+		displayToolErrors(grep.filter(entries, tools), tools)
+		return nil
+	}
+	if logConfig.inlineResults && cfg.OutputFormat == "text" {
+		entries = inlineResults(entries, tools)
 	}
 
-	if err := scanner.Err(); err != nil {
-		return err
+	switch cfg.OutputFormat {
+	case "html":
+		displayConversationAsHTML(grep.filter(entries, tools), tools)
+		return nil
+	case "markdown":
+		displayConversationAsMarkdown(grep.filter(entries, tools), tools)
+		return nil
 	}
 
 	// Second pass: display entries with tool name information
 	for _, entry := range entries {
-		displayEntryWithToolInfo(entry, toolUseMap, toolInputMap)
+		displayEntryWithGrep(entry, tools, grep)
 	}
 
 	return nil
 }
-
-// Collect tool use information from assistant messages
-func collectToolUseInfo(entry map[string]interface{}, toolUseMap map[string]string, toolInputMap map[string]map[string]interface{}) {
-	if message, ok := entry["message"].(map[string]interface{}); ok {
-		if content, ok := message["content"].([]interface{}); ok {
-			for _, item := range content {
-				if m, ok := item.(map[string]interface{}); ok {
-					if m["type"] == "tool_use" {
-						if toolID, ok := m["id"].(string); ok {
-							if toolName, ok := m["name"].(string); ok {
-								toolUseMap[toolID] = toolName
-							}
-							if input, ok := m["input"].(map[string]interface{}); ok {
-								toolInputMap[toolID] = input
-							}
-						}
-					}
-				}
-			}
-		}
-	}
-}
@@ -1,73 +1,65 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/Sixeight/ccl/pricing"
+	"github.com/Sixeight/ccl/transcript"
 )
 
 // Test data
-var testEntry = map[string]interface{}{
-	"type":      "user",
-	"timestamp": "2025-06-22T09:59:11.123Z",
-	"message": map[string]interface{}{
-		"content": []interface{}{
-			map[string]interface{}{
-				"type": "text",
-				"text": "Hello, world!",
-			},
+var testEntry = &transcript.Entry{
+	Type:      "user",
+	Timestamp: "2025-06-22T09:59:11.123Z",
+	Message: &transcript.Message{
+		Content: transcript.Content{
+			{Type: transcript.BlockText, Text: "Hello, world!"},
 		},
 	},
 }
 
-var testAssistantEntry = map[string]interface{}{
-	"type":      "assistant",
-	"timestamp": "2025-06-22T09:59:15.456Z",
-	"message": map[string]interface{}{
-		"model": "claude-sonnet-4-20250514",
-		"content": []interface{}{
-			map[string]interface{}{
-				"type": "text",
-				"text": "Hello! How can I help you today?",
-			},
+var testAssistantEntry = &transcript.Entry{
+	Type:      "assistant",
+	Timestamp: "2025-06-22T09:59:15.456Z",
+	Message: &transcript.Message{
+		Model: "claude-sonnet-4-20250514",
+		Content: transcript.Content{
+			{Type: transcript.BlockText, Text: "Hello! How can I help you today?"},
 		},
-		"usage": map[string]interface{}{
-			"input_tokens":  float64(10),
-			"output_tokens": float64(20),
+		Usage: &transcript.Usage{
+			InputTokens:  10,
+			OutputTokens: 20,
 		},
 	},
 }
 
-var testToolUseEntry = map[string]interface{}{
-	"type":      "assistant",
-	"timestamp": "2025-06-22T09:59:20.789Z",
-	"message": map[string]interface{}{
-		"model": "claude-sonnet-4-20250514",
-		"content": []interface{}{
-			map[string]interface{}{
-				"type": "tool_use",
-				"id":   "toolu_01ABC",
-				"name": "Bash",
-				"input": map[string]interface{}{
-					"command": "ls -la",
-				},
+var testToolUseEntry = &transcript.Entry{
+	Type:      "assistant",
+	Timestamp: "2025-06-22T09:59:20.789Z",
+	Message: &transcript.Message{
+		Model: "claude-sonnet-4-20250514",
+		Content: transcript.Content{
+			{
+				Type:  transcript.BlockToolUse,
+				ID:    "toolu_01ABC",
+				Name:  "Bash",
+				Input: json.RawMessage(`{"command":"ls -la"}`),
 			},
 		},
 	},
 }
 
-var testToolResultEntry = map[string]interface{}{
-	"type":            "tool",
-	"timestamp":       "2025-06-22T09:59:25.012Z",
-	"parentMessageId": "toolu_01ABC",
-	"toolUseResult": map[string]interface{}{
-		"content": []interface{}{
-			map[string]interface{}{
-				"type": "text",
-				"text": "file1.txt\nfile2.txt",
-			},
-		},
-		"isError": false,
-	},
+var testToolResultEntry = &transcript.Entry{
+	Type:            "tool",
+	Timestamp:       "2025-06-22T09:59:25.012Z",
+	ParentMessageID: "toolu_01ABC",
+	ToolUseResult:   []byte(`{"content":[{"type":"text","text":"file1.txt\nfile2.txt"}],"isError":false}`),
 }
 
 func TestFormatTimestamp(t *testing.T) {
@@ -161,51 +153,37 @@ func TestShortenProjectPaths(t *testing.T) {
 }
 
 // Extract text content from message - used only in tests
-func extractTextContent(message map[string]interface{}) string {
-	// Handle string content directly
-	if content, ok := message["content"].(string); ok {
-		return content
-	}
-
-	// Handle array content
-	if content, ok := message["content"].([]interface{}); ok {
-		for _, item := range content {
-			if m, ok := item.(map[string]interface{}); ok {
-				if m["type"] == "text" {
-					if text, ok := m["text"].(string); ok {
-						return text
-					}
-				}
-			}
-		}
+func extractTextContent(message *transcript.Message) string {
+	if text := message.Content.FirstOfType(transcript.BlockText); text != nil {
+		return text.Text
 	}
 	return ""
 }
 
 func TestExtractTextContent(t *testing.T) {
 	tests := []struct {
+		message  *transcript.Message
 		name     string
-		message  map[string]interface{}
 		expected string
 	}{
 		{
 			name:     "extract text from user message",
-			message:  testEntry["message"].(map[string]interface{}),
+			message:  testEntry.Message,
 			expected: "Hello, world!",
 		},
 		{
 			name:     "extract text from assistant message",
-			message:  testAssistantEntry["message"].(map[string]interface{}),
+			message:  testAssistantEntry.Message,
 			expected: "Hello! How can I help you today?",
 		},
 		{
 			name:     "no text content",
-			message:  testToolUseEntry["message"].(map[string]interface{}),
+			message:  testToolUseEntry.Message,
 			expected: "",
 		},
 		{
 			name:     "empty message",
-			message:  map[string]interface{}{},
+			message:  &transcript.Message{},
 			expected: "",
 		},
 	}
@@ -220,30 +198,68 @@ func TestExtractTextContent(t *testing.T) {
 	}
 }
 
-func TestGetTokenCount(t *testing.T) {
-	usage := map[string]interface{}{
-		"input_tokens":  float64(100),
-		"output_tokens": 200, // int
+func TestCalculateCost(t *testing.T) {
+	orig := modelPrices
+	defer func() { modelPrices = orig }()
+
+	modelPrices = pricing.NewResolver(pricing.Table{
+		"claude-sonnet-4-20250514": {
+			InputCostPerToken:       0.000003,
+			OutputCostPerToken:      0.000015,
+			CacheCreateCostPerToken: 0.00000375,
+			CacheReadCostPerToken:   0.0000003,
+		},
+	})
+
+	usage := transcript.Usage{
+		InputTokens:              1000,
+		OutputTokens:             1000,
+		CacheCreationInputTokens: 1000,
+		CacheReadInputTokens:     1000,
+	}
+	expected := 0.003 + 0.015 + 0.00375 + 0.0003
+	result := calculateCost(usage, "claude-sonnet-4-20250514")
+	if diff := result - expected; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("calculateCost() = %f; want %f", result, expected)
 	}
 
-	tests := []struct {
-		key      string
-		expected int
-		ok       bool
-	}{
-		{"input_tokens", 100, true},
-		{"output_tokens", 200, true},
-		{"missing_tokens", 0, false},
+	if result := calculateCost(usage, "unknown-model"); result != 0 {
+		t.Errorf("calculateCost(unknown) = %f; want 0", result)
 	}
+}
 
-	for _, tt := range tests {
-		result, ok := getTokenCount(usage, tt.key)
-		if ok != tt.ok {
-			t.Errorf("getTokenCount(%s) ok = %v; want %v", tt.key, ok, tt.ok)
-		}
-		if result != tt.expected {
-			t.Errorf("getTokenCount(%s) = %d; want %d", tt.key, result, tt.expected)
-		}
+func TestLoadModelPricing(t *testing.T) {
+	origPrices, origCfg := modelPrices, cfg
+	defer func() { modelPrices, cfg = origPrices, origCfg }()
+
+	dir := t.TempDir()
+	t.Setenv("CCL_CONFIG_DIR", dir)
+	if err := pricing.SaveCache(pricing.Table{"claude-cached": {InputCostPerToken: 2}}); err != nil {
+		t.Fatalf("SaveCache() error = %v", err)
+	}
+	override := filepath.Join(dir, "override.json")
+	if err := os.WriteFile(override, []byte(`{"claude-cached":{"input_cost_per_token":3},"claude-custom":{"input_cost_per_token":4}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.PricingFile = override
+	if err := loadModelPricing(); err != nil {
+		t.Fatalf("loadModelPricing() error = %v", err)
+	}
+	table := modelPrices.Table()
+	if _, ok := table["claude-sonnet-4-20250514"]; !ok {
+		t.Error("embedded prices missing after load")
+	}
+	if got := table["claude-cached"].InputCostPerToken; got != 3 {
+		t.Errorf("override price = %v; want 3", got)
+	}
+	if got := table["claude-custom"].InputCostPerToken; got != 4 {
+		t.Errorf("custom price = %v; want 4", got)
+	}
+
+	cfg.PricingFile = filepath.Join(dir, "missing.json")
+	if err := loadModelPricing(); err == nil {
+		t.Error("loadModelPricing() with missing override should fail")
 	}
 }
 
@@ -311,16 +327,66 @@ func TestJSONOutput(t *testing.T) {
 	cfg.OutputFormat = "json"
 	defer func() { cfg.OutputFormat = old }()
 
-	// Since we can't easily capture stdout in the test,
-	// we'll just verify the function doesn't panic
-	// In a real scenario, we'd refactor displayEntryAsJSON to use io.Writer
-	displayEntryAsJSON(testEntry, nil)
+	var buf bytes.Buffer
+	for _, entry := range []*transcript.Entry{testEntry, testAssistantEntry, testToolResultEntry} {
+		displayEntryAsJSON(&buf, entry)
+	}
+
+	// One JSON object per entry
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("displayEntryAsJSON() wrote %d lines; want 3", len(lines))
+	}
+	for _, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Errorf("displayEntryAsJSON() line %q is not JSON", line)
+		}
+	}
+}
 
-	// Test with assistant message
-	displayEntryAsJSON(testAssistantEntry, nil)
+func TestJSONOutputCost(t *testing.T) {
+	origCfg, origPrices := cfg, modelPrices
+	defer func() { cfg, modelPrices = origCfg, origPrices }()
+	cfg.OutputFormat, cfg.ShowCost = "json", true
+	modelPrices = pricing.NewResolver(pricing.Table{
+		"claude-sonnet-4-20250514": {InputCostPerToken: 0.000003, OutputCostPerToken: 0.000015},
+	})
+
+	tests := map[string]struct {
+		line     string
+		expected string // cost_usd and price_key; absent when empty
+	}{
+		"priced":   {`{"type":"assistant","message":{"model":"claude-sonnet-4-20250514","content":"hi","usage":{"input_tokens":1000,"output_tokens":100}}}`, "0.0045 claude-sonnet-4-20250514"},
+		"unpriced": {`{"type":"assistant","message":{"model":"claude-future-9","content":"hi","usage":{"input_tokens":1000}}}`, "<nil> <nil>"},
+		"no usage": {`{"type":"user","message":{"content":"hello"}}`, ""},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			entry, err := transcript.ParseEntry([]byte(tt.line))
+			if err != nil {
+				t.Fatalf("ParseEntry() error = %v", err)
+			}
+			var buf bytes.Buffer
+			displayEntryAsJSON(&buf, entry)
 
-	// Test with tool result
-	displayEntryAsJSON(testToolResultEntry, nil)
+			var got map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("output %q is not JSON: %v", buf.String(), err)
+			}
+			cost, hasCost := got["cost_usd"]
+			priceKey, hasKey := got["price_key"]
+			summary := ""
+			if hasCost || hasKey {
+				summary = fmt.Sprintf("%.4g %v", cost, priceKey)
+				if cost == nil {
+					summary = fmt.Sprintf("%v %v", cost, priceKey)
+				}
+			}
+			if summary != tt.expected {
+				t.Errorf("cost_usd and price_key = %q; want %q", summary, tt.expected)
+			}
+		})
+	}
 }
 
 func TestParseRoles(t *testing.T) {
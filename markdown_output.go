@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+// displayConversationAsMarkdown writes the conversation as GitHub-flavored Markdown,
+// with a heading for each turn started by a user prompt
+func displayConversationAsMarkdown(entries []*transcript.Entry, tools transcript.ToolIndex) {
+	displayMarkdownHeader(entries)
+
+	turn := 0
+	headed := false
+	for _, entry := range entries {
+		if entry.IsPrompt() {
+			turn++
+			headed = false
+		}
+		if !shouldDisplayEntryWithToolInfo(entry.Type, entry, tools) {
+			continue
+		}
+		if entry.Message == nil || (entry.Type != "user" && entry.Type != "assistant") {
+			continue
+		}
+		if turn > 0 && !headed {
+			fmt.Fprintf(output, "## Turn %d", turn)
+			if t, ok := entry.Time(); ok {
+				fmt.Fprintf(output, " · %s", t.Local().Format("2006-01-02 15:04:05"))
+			}
+			fmt.Fprintf(output, "\n\n")
+			headed = true
+		}
+		displayEntryAsMarkdown(entry, tools)
+	}
+}
+
+// displayMarkdownHeader writes the document title and session totals
+func displayMarkdownHeader(entries []*transcript.Entry) {
+	stats := collectSessionStats(entries)
+
+	fmt.Fprintf(output, "# %s\n\n", conversationTitle(entries))
+	var meta []string
+	if !stats.Start.IsZero() {
+		meta = append(meta,
+			stats.Start.Local().Format("2006-01-02 15:04"),
+			stats.End.Sub(stats.Start).Round(time.Second).String())
+	}
+	meta = append(meta,
+		fmt.Sprintf("%d prompts", stats.UserPrompts),
+		fmt.Sprintf("%d tool calls", stats.totalToolCalls()),
+		fmt.Sprintf("%s tokens", formatNumber(stats.Usage.Total())))
+	if cfg.ShowCost {
+		calculateStatsCost(stats)
+		meta = append(meta, fmt.Sprintf("$%.4f", stats.Cost))
+	}
+	fmt.Fprintf(output, "_%s_\n\n", strings.Join(meta, " · "))
+}
+
+// displayEntryAsMarkdown writes the content blocks of one entry
+func displayEntryAsMarkdown(entry *transcript.Entry, tools transcript.ToolIndex) {
+	for i := range entry.Message.Content {
+		block := &entry.Message.Content[i]
+		switch block.Type {
+		case transcript.BlockText:
+			text := strings.TrimSpace(block.Text)
+			if text == "" {
+				continue
+			}
+			if entry.Type == "user" {
+				fmt.Fprintf(output, "%s\n\n", markdownQuote(text))
+			} else {
+				fmt.Fprintf(output, "%s\n\n", text)
+			}
+		case transcript.BlockToolUse:
+			displayToolUseAsMarkdown(block, tools)
+		case transcript.BlockToolResult:
+			displayToolResultAsMarkdown(entry, block, tools)
+		case transcript.BlockThinking, transcript.BlockRedactedThinking:
+			if !logConfig.noThinking {
+				displayThinkingAsMarkdown(block)
+			}
+		}
+	}
+}
+
+// displayToolUseAsMarkdown writes a tool call as a label and a fenced input block
+func displayToolUseAsMarkdown(block *transcript.ContentBlock, tools transcript.ToolIndex) {
+	summary := toolUseSummary(block)
+	fmt.Fprintf(output, "**%s**", block.Name)
+	switch {
+	case summary == "" || block.Name == "Bash":
+	case block.Name == "TodoWrite":
+		fmt.Fprintf(output, " (%s)", summary)
+	default:
+		fmt.Fprintf(output, " %s", markdownCode(summary))
+	}
+	fmt.Fprintf(output, "\n\n")
+
+	switch block.Name {
+	case "Bash":
+		if command := block.BashInput().Command; command != "" {
+			fmt.Fprintf(output, "%s\n", markdownFence(command, "bash"))
+			return
+		}
+	case "Edit", "MultiEdit":
+		result, _ := tools.Result(block.ID)
+		if hunks, ok := toolDiffHunks(block, result); ok {
+			fmt.Fprintf(output, "%s\n", markdownFence(formatHunks(hunks), "diff"))
+			return
+		}
+	case "Write":
+		if content := block.FileInput().Content; content != "" {
+			fmt.Fprintf(output, "%s\n", markdownFence(content, ""))
+			return
+		}
+	case "TodoWrite":
+		if todos := block.Todos(); len(todos) > 0 {
+			displayTodosAsMarkdown(todos)
+			return
+		}
+	}
+
+	// The summary already shows a lone argument such as Read's file_path
+	input := block.InputFields()
+	if len(input) == 0 || (len(input) == 1 && toolInputText(input) == summary) {
+		return
+	}
+	data, err := json.MarshalIndent(input, "", "  ")
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(output, "%s\n", markdownFence(string(data), "json"))
+}
+
+// displayToolResultAsMarkdown writes a tool result as a collapsed <details> block
+func displayToolResultAsMarkdown(entry *transcript.Entry, result *transcript.ContentBlock, tools transcript.ToolIndex) {
+	toolName := tools.Name(result.ToolUseID)
+	label := "Result"
+	if toolName != "" {
+		label = toolName + " result"
+	}
+	open := ""
+	if result.IsError {
+		label = "❌ " + label + " (error)"
+		open = " open"
+	}
+
+	fmt.Fprintf(output, "<details%s>\n<summary>%s</summary>\n\n", open, label)
+	if toolName == "TodoWrite" && !result.IsError {
+		if todos, ok := transcript.TodosFromResult(entry.ToolUseResultMap()); ok {
+			displayTodosAsMarkdown(todos)
+			fmt.Fprintf(output, "</details>\n\n")
+			return
+		}
+	}
+	text := result.Content.Text()
+	if text == "" {
+		text = "(No content)"
+	}
+	fmt.Fprintf(output, "%s\n</details>\n\n", markdownFence(text, ""))
+}
+
+// displayTodosAsMarkdown writes a todo list as a task list
+func displayTodosAsMarkdown(todos []transcript.Todo) {
+	for _, todo := range todos {
+		mark := " "
+		if todo.Status == "completed" {
+			mark = "x"
+		}
+		content := todo.Content
+		if todo.Status == "in_progress" {
+			content = "**" + content + "** (in progress)"
+		}
+		fmt.Fprintf(output, "- [%s] %s\n", mark, content)
+	}
+	fmt.Fprintln(output)
+}
+
+// markdownFence wraps text in a fenced code block, using a fence longer
+// than any backtick run inside the text
+func markdownFence(text, lang string) string {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + strings.TrimRight(text, "\n") + "\n" + fence + "\n"
+}
+
+// markdownCode wraps a single line in an inline code span
+func markdownCode(text string) string {
+	fence := "`"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		return fence + " " + text + " " + fence
+	}
+	return fence + text + fence
+}
+
+// markdownQuote prefixes every line of text as a blockquote
+func markdownQuote(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
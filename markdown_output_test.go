@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+func TestDisplayConversationAsMarkdown(t *testing.T) {
+	origCfg, origOutput := cfg, output
+	defer func() {
+		cfg, output = origCfg, origOutput
+	}()
+	cfg = Config{OutputFormat: "markdown", NoColor: true}
+
+	input := strings.Join([]string{
+		`{"type":"summary","summary":"Fix greeting","leafUuid":"a3"}`,
+		`{"type":"user","uuid":"u1","timestamp":"2025-06-22T09:00:00Z","message":{"role":"user","content":"Fix the typo\n\nin main.go"}}`,
+		`{"type":"assistant","uuid":"a1","parentUuid":"u1","timestamp":"2025-06-22T09:00:05Z","message":{"content":[{"type":"text","text":"Running **tests**."},{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"go test ./...","description":"Test"}}]}}`,
+		`{"type":"user","uuid":"u2","parentUuid":"a1","timestamp":"2025-06-22T09:00:10Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"FAIL: ` + "```" + `quoted` + "```" + `","is_error":true}]}}`,
+		`{"type":"assistant","uuid":"a2","parentUuid":"u2","timestamp":"2025-06-22T09:00:15Z","message":{"content":[{"type":"tool_use","id":"t2","name":"Edit","input":{"file_path":"main.go","old_string":"helo","new_string":"hello"}},{"type":"tool_use","id":"t3","name":"TodoWrite","input":{"todos":[{"id":"1","content":"Fix typo","status":"completed","priority":"high"},{"id":"2","content":"Run tests","status":"in_progress","priority":"low"}]}}]}}`,
+		`{"type":"user","uuid":"u3","parentUuid":"a2","timestamp":"2025-06-22T09:01:00Z","message":{"role":"user","content":"Thanks"}}`,
+	}, "\n")
+	entries, err := transcript.ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	output = &buf
+	displayConversationAsMarkdown(entries, transcript.NewToolIndex(entries))
+	got := buf.String()
+
+	for _, want := range []string{
+		"# Fix greeting\n",
+		"## Turn 1 · ",
+		"> Fix the typo\n>\n> in main.go\n",
+		"Running **tests**.\n",
+		"```bash\ngo test ./...\n```\n",
+		"<details open>\n<summary>❌ Bash result (error)</summary>\n\n````\nFAIL: ```quoted```\n````\n\n</details>",
+		"**Edit** `main.go`\n\n```diff\n@@\n-helo\n+hello\n```\n",
+		"- [x] Fix typo\n- [ ] **Run tests** (in progress)\n",
+		"## Turn 2 · ",
+		"> Thanks\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output does not contain %q\n%s", want, got)
+		}
+	}
+}
+
+func TestMarkdownCode(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected string
+	}{
+		"plain":            {"main.go", "`main.go`"},
+		"inner backtick":   {"a`b", "``a`b``"},
+		"leading backtick": {"`x", "`` `x ``"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := markdownCode(tt.input); got != tt.expected {
+				t.Errorf("markdownCode(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
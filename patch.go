@@ -0,0 +1,189 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+// Marker git uses for a last line without a newline
+const noNewlineMarker = "\\ No newline at end of file"
+
+// replayFilesSince replays a session, recording changes only after the entry
+// with the given UUID; an empty UUID records from the start
+func replayFilesSince(entries []*transcript.Entry, since string) (*fileReplay, error) {
+	tools := transcript.NewToolIndex(entries)
+	replay := newFileReplay()
+	replay.recording = since == ""
+
+	found := since == ""
+	for _, entry := range entries {
+		replay.apply(entry, tools)
+		if !found && entry.UUID == since {
+			found = true
+			replay.recording = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("entry %s not found in session", since)
+	}
+	return replay, nil
+}
+
+// sessionCwd returns the working directory recorded in the session
+func sessionCwd(entries []*transcript.Entry) string {
+	for _, entry := range entries {
+		if entry.Cwd != "" {
+			return entry.Cwd
+		}
+	}
+	return ""
+}
+
+// patchLines splits text into lines for a patch, marking a last line
+// without a newline so that it differs from the same line with one
+func patchLines(text string) []string {
+	lines := splitLines(text)
+	if len(lines) > 0 && !strings.HasSuffix(text, "\n") {
+		lines[len(lines)-1] += "\n" + noNewlineMarker
+	}
+	return lines
+}
+
+// filePatch renders the change of one file as a git diff. The path must be
+// relative to the directory the patch is applied in.
+func filePatch(path, oldText, newText string, created bool) string {
+	hunks := diffLineHunks(patchLines(oldText), patchLines(newText), diffContext)
+	path = filepath.ToSlash(path)
+	var b strings.Builder
+	switch {
+	case len(hunks) > 0:
+	case created:
+		// git marks an empty new file by its header alone
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\nnew file mode 100644\n", path, path)
+		return b.String()
+	default:
+		return ""
+	}
+
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\n", path, path)
+	if created {
+		fmt.Fprintf(&b, "new file mode 100644\n--- /dev/null\n")
+	} else {
+		fmt.Fprintf(&b, "--- a/%s\n", path)
+	}
+	fmt.Fprintf(&b, "+++ b/%s\n", path)
+	b.WriteString(formatHunks(hunks))
+	return b.String()
+}
+
+// recordedPatch renders the structured patches Claude Code recorded for a
+// file as one git diff per change, each applying on top of the previous one
+func recordedPatch(path string, patches [][]diffHunk) string {
+	path = filepath.ToSlash(path)
+	var b strings.Builder
+	for _, hunks := range patches {
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n", path, path, path, path)
+		b.WriteString(formatHunks(hunks))
+	}
+	return b.String()
+}
+
+// sessionPatch builds a patch of every file changed while recording, with
+// paths relative to root. A file changed before its content was read is
+// patched with the hunks recorded in the tool results. Files whose change
+// cannot be reconstructed are reported as warnings.
+func sessionPatch(replay *fileReplay, root string) (string, []string) {
+	var patch strings.Builder
+	var warnings []string
+	for _, path := range replay.order {
+		state := replay.files[path]
+		if !state.baseSet {
+			continue
+		}
+
+		rel := path
+		if root != "" {
+			if r, err := filepath.Rel(root, path); err == nil {
+				rel = r
+			}
+		}
+		switch {
+		case isOutside(rel):
+			warnings = append(warnings, fmt.Sprintf("%s: outside of %s", path, root))
+		case !state.baseKnown && state.unpatched == 0:
+			patch.WriteString(recordedPatch(rel, state.patches))
+		case !state.baseKnown:
+			warnings = append(warnings, fmt.Sprintf("%s: edited before its content was read", path))
+		case state.Status != fileComplete:
+			warnings = append(warnings, fmt.Sprintf("%s: %s, some edits could not be replayed", path, state.Status))
+		default:
+			patch.WriteString(filePatch(rel, state.base, state.content, state.created))
+		}
+	}
+	return patch.String(), warnings
+}
+
+// isOutside reports whether a path relative to the patch root leaves it
+func isOutside(rel string) bool {
+	return filepath.IsAbs(rel) || strings.HasPrefix(rel, "/") ||
+		rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// runPatchCommand runs the patch subcommand
+func runPatchCommand(args []string) {
+	patchCmd := flag.NewFlagSet("patch", flag.ExitOnError)
+	patchCmd.StringVar(&cfg.ProjectPath, "p", "", "path to Claude Code project file")
+	since := patchCmd.String("since", "", "only include changes made after the entry with this UUID")
+	root := patchCmd.String("root", "", "directory paths are made relative to (default: the session's working directory)")
+
+	patchCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ccl patch [options] [file]\n\n")
+		fmt.Fprintf(os.Stderr, "Print the file changes made by a session as a patch for git apply.\n")
+		fmt.Fprintf(os.Stderr, "Calls whose result is an error are skipped.\n")
+		fmt.Fprintf(os.Stderr, "Defaults to the latest session of the current directory.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		patchCmd.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  ccl patch > agent.patch\n")
+		fmt.Fprintf(os.Stderr, "  ccl patch --since 1a2b3c4d-... session.jsonl | git -C ../other apply\n")
+	}
+
+	if err := patchCmd.Parse(args); err != nil {
+		return
+	}
+
+	reader, cleanup, err := getInputReader(patchCmd)
+	if cleanup != nil {
+		defer cleanup()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	entries, err := transcript.ReadAll(reader)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	replay, err := replayFilesSince(entries, *since)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	dir := *root
+	if dir == "" {
+		dir = sessionCwd(entries)
+	}
+	patch, warnings := sessionPatch(replay, dir)
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: skipped %s\n", warning)
+	}
+	fmt.Fprint(output, patch)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+func TestFilePatch(t *testing.T) {
+	tests := map[string]struct {
+		oldText  string
+		newText  string
+		created  bool
+		expected string
+	}{
+		"unchanged": {"a\n", "a\n", false, ""},
+		"modified": {
+			"a\nb\n", "a\nB\n", false,
+			"diff --git a/f.txt b/f.txt\n--- a/f.txt\n+++ b/f.txt\n@@ -1,2 +1,2 @@\n a\n-b\n+B\n",
+		},
+		"newline added at end": {
+			"a", "a\n", false,
+			"diff --git a/f.txt b/f.txt\n--- a/f.txt\n+++ b/f.txt\n@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+a\n",
+		},
+		"created": {
+			"", "x\n", true,
+			"diff --git a/f.txt b/f.txt\nnew file mode 100644\n--- /dev/null\n+++ b/f.txt\n@@ -0,0 +1 @@\n+x\n",
+		},
+		"created empty": {"", "", true, "diff --git a/f.txt b/f.txt\nnew file mode 100644\n"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := filePatch("f.txt", tt.oldText, tt.newText, tt.created); got != tt.expected {
+				t.Errorf("filePatch() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSessionPatch(t *testing.T) {
+	input := strings.Join([]string{
+		`{"type":"assistant","uuid":"a1","cwd":"/work","message":{"content":[{"type":"tool_use","id":"e1","name":"Edit","input":{"file_path":"/work/a.txt","old_string":"one","new_string":"ONE"}}]}}`,
+		`{"type":"user","uuid":"u1","message":{"content":[{"type":"tool_result","tool_use_id":"e1","content":"ok"}]},"toolUseResult":{"originalFile":"one\ntwo\n"}}`,
+		`{"type":"assistant","uuid":"a2","cwd":"/work","message":{"content":[{"type":"tool_use","id":"e2","name":"Edit","input":{"file_path":"/work/a.txt","old_string":"two","new_string":"TWO"}},{"type":"tool_use","id":"e3","name":"Edit","input":{"file_path":"/work/a.txt","old_string":"ONE","new_string":"uno"}}]}}`,
+		`{"type":"user","uuid":"u2","message":{"content":[{"type":"tool_result","tool_use_id":"e2","content":"ok"}]}}`,
+		`{"type":"user","uuid":"u3","message":{"content":[{"type":"tool_result","tool_use_id":"e3","content":"rejected","is_error":true}]}}`,
+		`{"type":"assistant","uuid":"a3","cwd":"/work","message":{"content":[{"type":"tool_use","id":"e4","name":"Edit","input":{"file_path":"/work/b.txt","old_string":"x","new_string":"y"}},{"type":"tool_use","id":"w1","name":"Write","input":{"file_path":"/elsewhere/c.txt","content":"c\n"}}]}}`,
+		`{"type":"user","uuid":"u4","message":{"content":[{"type":"tool_result","tool_use_id":"e4","content":"ok"},{"type":"tool_result","tool_use_id":"w1","content":"ok"}]}}`,
+	}, "\n")
+	entries, err := transcript.ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	tests := map[string]struct {
+		since    string
+		expected string
+		warnings int
+	}{
+		"whole session": {
+			"", "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,2 @@\n-one\n-two\n+ONE\n+TWO\n", 2,
+		},
+		"since first edit": {
+			"u1", "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,2 @@\n ONE\n-two\n+TWO\n", 2,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			replay, err := replayFilesSince(entries, tt.since)
+			if err != nil {
+				t.Fatalf("replayFilesSince() error = %v", err)
+			}
+			patch, warnings := sessionPatch(replay, sessionCwd(entries))
+			if patch != tt.expected {
+				t.Errorf("patch = %q; want %q", patch, tt.expected)
+			}
+			if len(warnings) != tt.warnings {
+				t.Errorf("warnings = %q; want %d", warnings, tt.warnings)
+			}
+		})
+	}
+
+	if _, err := replayFilesSince(entries, "missing"); err == nil {
+		t.Error("replayFilesSince() with an unknown UUID succeeded")
+	}
+}
+
+func TestSessionPatchRecordedHunks(t *testing.T) {
+	// a.txt is edited twice without being read; the results carry the hunks
+	input := strings.Join([]string{
+		`{"type":"assistant","uuid":"a1","cwd":"/work","message":{"content":[{"type":"tool_use","id":"e1","name":"Edit","input":{"file_path":"/work/a.txt","old_string":"b","new_string":"B"}},{"type":"tool_use","id":"w1","name":"Write","input":{"file_path":"/work/empty.txt","content":""}}]}}`,
+		`{"type":"user","uuid":"u1","message":{"content":[{"type":"tool_result","tool_use_id":"e1","content":"ok"}]},"toolUseResult":{"structuredPatch":[{"oldStart":1,"oldLines":3,"newStart":1,"newLines":3,"lines":[" a","-b","+B"," c"]}]}}`,
+		`{"type":"user","uuid":"u2","message":{"content":[{"type":"tool_result","tool_use_id":"w1","content":"ok"}]},"toolUseResult":{"type":"create","structuredPatch":[]}}`,
+		`{"type":"assistant","uuid":"a2","cwd":"/work","message":{"content":[{"type":"tool_use","id":"e2","name":"Edit","input":{"file_path":"/work/a.txt","old_string":"y","new_string":"Y"}}]}}`,
+		`{"type":"user","uuid":"u3","message":{"content":[{"type":"tool_result","tool_use_id":"e2","content":"ok"}]},"toolUseResult":{"structuredPatch":[{"oldStart":9,"oldLines":1,"newStart":9,"newLines":1,"lines":["-y","+Y"]}]}}`,
+	}, "\n")
+	entries, err := transcript.ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	patch, warnings := sessionPatch(replayFiles(entries), "/work")
+	expected := "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n" +
+		"diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -9 +9 @@\n-y\n+Y\n" +
+		"diff --git a/empty.txt b/empty.txt\nnew file mode 100644\n"
+	if patch != expected {
+		t.Errorf("patch = %q; want %q", patch, expected)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("warnings = %q; want none", warnings)
+	}
+}
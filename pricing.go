@@ -1,116 +1,86 @@
 package main
 
 import (
-	"encoding/json"
+	"flag"
 	"fmt"
-	"net/http"
-	"strings"
-)
-
-// Model pricing structure
-type modelPricing struct {
-	InputCostPerToken       float64 `json:"input_cost_per_token"`
-	OutputCostPerToken      float64 `json:"output_cost_per_token"`
-	CacheCreateCostPerToken float64 `json:"cache_creation_input_token_cost"`
-	CacheReadCostPerToken   float64 `json:"cache_read_input_token_cost"`
-}
-
-// Global variable to store fetched prices
-var modelPrices map[string]modelPricing
+	"os"
+	"time"
 
-// Fetch latest pricing from LiteLLM
-func fetchModelPricing() error {
-	const pricingURL = "https://raw.githubusercontent.com/BerriAI/litellm/main/model_prices_and_context_window.json"
-
-	resp, err := http.Get(pricingURL)
-	if err != nil {
-		return fmt.Errorf("failed to fetch pricing: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
+	"github.com/Sixeight/ccl/pricing"
+	"github.com/Sixeight/ccl/transcript"
+)
 
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("failed to fetch pricing: status %d", resp.StatusCode)
-	}
+// Global variable to store loaded prices
+var modelPrices *pricing.Resolver
 
-	var allPricing map[string]modelPricing
-	if err := json.NewDecoder(resp.Body).Decode(&allPricing); err != nil {
-		return fmt.Errorf("failed to decode pricing data: %w", err)
-	}
+// Load prices from the embedded table, overlaid by the fetch cache and the --pricing file.
+// Never touches the network; use 'ccl pricing update' to refresh the cache.
+func loadModelPricing() error {
+	table := pricing.Default()
 
-	// Filter and store only Claude models
-	modelPrices = make(map[string]modelPricing)
-	for model, pricing := range allPricing {
-		if strings.Contains(model, "claude") {
-			modelPrices[model] = pricing
+	if cached, fetchedAt, err := pricing.LoadCache(); err == nil {
+		table = table.Merge(cached)
+		if age := time.Since(fetchedAt); age > pricing.CacheTTL {
+			fmt.Fprintf(os.Stderr, "Note: pricing cache is %s old; run 'ccl pricing update' to refresh\n", formatDuration(age))
 		}
 	}
 
-	if len(modelPrices) == 0 {
-		return fmt.Errorf("no Claude pricing data found")
-	}
-
-	return nil
-}
-
-// Get pricing for a model by matching model name
-func getModelPricing(modelName string) modelPricing {
-	if modelPrices == nil {
-		return modelPricing{} // Return zero values if prices not loaded
-	}
-
-	// Try exact match first
-	if price, ok := modelPrices[modelName]; ok {
-		return price
-	}
-
-	// Try various matching strategies
-	for key, price := range modelPrices {
-		// Check if model name contains the key
-		if strings.Contains(modelName, key) {
-			return price
-		}
-		// Check if key contains model name parts
-		if strings.Contains(key, "opus") && strings.Contains(modelName, "opus") {
-			return price
-		}
-		if strings.Contains(key, "sonnet") && strings.Contains(modelName, "sonnet") {
-			return price
-		}
-		if strings.Contains(key, "haiku") && strings.Contains(modelName, "haiku") {
-			return price
+	if cfg.PricingFile != "" {
+		override, err := pricing.LoadFile(cfg.PricingFile)
+		if err != nil {
+			return fmt.Errorf("loading pricing file: %w", err)
 		}
+		table = table.Merge(override)
 	}
 
-	// Return zero values if not found
-	return modelPricing{}
+	modelPrices = pricing.NewResolver(table)
+	return nil
 }
 
 // Calculate cost based on token usage and model
-func calculateCost(usage map[string]interface{}, modelName string) float64 {
-	inputTokens, _ := getTokenCount(usage, "input_tokens")
-	outputTokens, _ := getTokenCount(usage, "output_tokens")
-	cacheCreateTokens, _ := getTokenCount(usage, "cache_creation_input_tokens")
-	cacheReadTokens, _ := getTokenCount(usage, "cache_read_input_tokens")
+func calculateCost(usage transcript.Usage, modelName string) float64 {
+	return modelPrices.Cost(usage, modelName)
+}
 
-	pricing := getModelPricing(modelName)
+// Get the price table key used for a model, or "" if it is not priced
+func priceKeyForModel(modelName string) string {
+	key, _, _ := modelPrices.Resolve(modelName)
+	return key
+}
 
-	inputCost := float64(inputTokens) * pricing.InputCostPerToken
-	outputCost := float64(outputTokens) * pricing.OutputCostPerToken
-	cacheCreateCost := float64(cacheCreateTokens) * pricing.CacheCreateCostPerToken
-	cacheReadCost := float64(cacheReadTokens) * pricing.CacheReadCostPerToken
+// runPricingCommand runs the pricing subcommand
+func runPricingCommand(args []string) {
+	pricingCmd := flag.NewFlagSet("pricing", flag.ExitOnError)
+
+	pricingCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ccl pricing update\n\n")
+		fmt.Fprintf(os.Stderr, "Manage the model price table used for cost calculation.\n\n")
+		fmt.Fprintf(os.Stderr, "Commands:\n")
+		fmt.Fprintf(os.Stderr, "  update   Fetch the latest prices from LiteLLM into the local cache\n\n")
+		fmt.Fprintf(os.Stderr, "Prices are resolved from, in increasing priority:\n")
+		fmt.Fprintf(os.Stderr, "  1. the table bundled with ccl\n")
+		fmt.Fprintf(os.Stderr, "  2. the cache at %s\n", pricing.CachePath())
+		fmt.Fprintf(os.Stderr, "  3. a file passed with --pricing (same schema as LiteLLM)\n")
+	}
 
-	return inputCost + outputCost + cacheCreateCost + cacheReadCost
-}
+	if err := pricingCmd.Parse(args); err != nil {
+		return
+	}
 
-// Helper function to get token count from usage data
-func getTokenCount(usage map[string]interface{}, key string) (int, bool) {
-	if val, ok := usage[key]; ok {
-		switch v := val.(type) {
-		case float64:
-			return int(v), true
-		case int:
-			return v, true
+	switch pricingCmd.Arg(0) {
+	case "update":
+		table, err := pricing.Fetch()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := pricing.SaveCache(table); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: saving pricing cache: %v\n", err)
+			os.Exit(1)
 		}
+		fmt.Printf("Updated %d model prices in %s\n", len(table), pricing.CachePath())
+	default:
+		pricingCmd.Usage()
+		os.Exit(1)
 	}
-	return 0, false
 }
@@ -0,0 +1,87 @@
+package pricing
+
+import (
+	_ "embed" // For the default price table
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+//go:embed default_prices.json
+var defaultPrices []byte
+
+// CacheTTL is how long a fetched price table is considered fresh
+const CacheTTL = 7 * 24 * time.Hour
+
+// Default returns the price table bundled with ccl
+func Default() Table {
+	table, err := Parse(defaultPrices)
+	if err != nil {
+		panic(fmt.Sprintf("embedded price table: %v", err))
+	}
+	return table
+}
+
+// ConfigDir returns the ccl configuration directory:
+// 1. CCL_CONFIG_DIR environment variable
+// 2. the user config directory (e.g. ~/.config/ccl)
+func ConfigDir() string {
+	if dir := os.Getenv("CCL_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ccl")
+}
+
+// CachePath returns the location of the cached LiteLLM price table
+func CachePath() string {
+	dir := ConfigDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "pricing.json")
+}
+
+// LoadCache reads the cached price table and reports when it was fetched
+func LoadCache() (Table, time.Time, error) {
+	path := CachePath()
+	if path == "" {
+		return nil, time.Time{}, fmt.Errorf("could not determine ccl config directory")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	table, err := LoadFile(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return table, info.ModTime(), nil
+}
+
+// SaveCache writes a fetched price table to the cache
+func SaveCache(table Table) error {
+	path := CachePath()
+	if path == "" {
+		return fmt.Errorf("could not determine ccl config directory")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(table, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write through a temporary file so a failed update keeps the old cache
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
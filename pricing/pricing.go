@@ -0,0 +1,121 @@
+// Package pricing loads Claude model prices and computes token costs.
+//
+// Prices come from a table embedded in the binary, optionally overlaid by
+// a cache of the last LiteLLM fetch and by a user-supplied override file.
+package pricing
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+// ModelPricing holds per-token prices for a model, using LiteLLM's schema
+type ModelPricing struct {
+	InputCostPerToken       float64 `json:"input_cost_per_token"`
+	OutputCostPerToken      float64 `json:"output_cost_per_token"`
+	CacheCreateCostPerToken float64 `json:"cache_creation_input_token_cost"`
+	CacheReadCostPerToken   float64 `json:"cache_read_input_token_cost"`
+}
+
+// Table maps model names to their prices
+type Table map[string]ModelPricing
+
+// SourceURL is the LiteLLM price list used by Fetch
+const SourceURL = "https://raw.githubusercontent.com/BerriAI/litellm/main/model_prices_and_context_window.json"
+
+// Fetch downloads the latest pricing from LiteLLM, keeping only Claude models
+func Fetch() (Table, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(SourceURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pricing: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("failed to fetch pricing: status %d", resp.StatusCode)
+	}
+
+	var allPricing Table
+	if err := json.NewDecoder(resp.Body).Decode(&allPricing); err != nil {
+		return nil, fmt.Errorf("failed to decode pricing data: %w", err)
+	}
+
+	// Filter and store only Claude models
+	table := make(Table)
+	for model, pricing := range allPricing {
+		if strings.Contains(model, "claude") {
+			table[model] = pricing
+		}
+	}
+
+	if len(table) == 0 {
+		return nil, fmt.Errorf("no Claude pricing data found")
+	}
+
+	return table, nil
+}
+
+// Parse decodes a price table in LiteLLM's schema
+func Parse(data []byte) (Table, error) {
+	var table Table
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("failed to decode pricing data: %w", err)
+	}
+	return table, nil
+}
+
+// LoadFile reads a price table from a JSON file
+func LoadFile(path string) (Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	table, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return table, nil
+}
+
+// Merge returns a new table with the entries of each overlay replacing
+// those of t, applied in order
+func (t Table) Merge(overlays ...Table) Table {
+	merged := make(Table, len(t))
+	for model, price := range t {
+		merged[model] = price
+	}
+	for _, overlay := range overlays {
+		for model, price := range overlay {
+			merged[model] = price
+		}
+	}
+	return merged
+}
+
+// Lookup gets pricing for a model, see Resolver for the matching rules
+func (t Table) Lookup(modelName string) ModelPricing {
+	_, price, _ := NewResolver(t).Resolve(modelName)
+	return price
+}
+
+// Cost calculates the cost of token usage at the given prices
+func (p ModelPricing) Cost(usage transcript.Usage) float64 {
+	inputCost := float64(usage.InputTokens) * p.InputCostPerToken
+	outputCost := float64(usage.OutputTokens) * p.OutputCostPerToken
+	cacheCreateCost := float64(usage.CacheCreationInputTokens) * p.CacheCreateCostPerToken
+	cacheReadCost := float64(usage.CacheReadInputTokens) * p.CacheReadCostPerToken
+
+	return inputCost + outputCost + cacheCreateCost + cacheReadCost
+}
+
+// Cost calculates the cost of token usage for a model
+func (t Table) Cost(usage transcript.Usage, modelName string) float64 {
+	return t.Lookup(modelName).Cost(usage)
+}
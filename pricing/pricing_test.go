@@ -0,0 +1,125 @@
+package pricing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+func TestDefault(t *testing.T) {
+	table := Default()
+	price, ok := table["claude-sonnet-4-20250514"]
+	if !ok {
+		t.Fatal("Default() is missing claude-sonnet-4-20250514")
+	}
+	cost := price.Cost(transcript.Usage{InputTokens: 1000000, OutputTokens: 1000000})
+	if cost != 18 {
+		t.Errorf("claude-sonnet-4 cost for 1M in/out = %v; want 18", cost)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := Table{
+		"a": {InputCostPerToken: 1},
+		"b": {InputCostPerToken: 2},
+	}
+	merged := base.Merge(Table{"b": {InputCostPerToken: 20}}, Table{"c": {InputCostPerToken: 3}})
+
+	if merged["a"].InputCostPerToken != 1 || merged["b"].InputCostPerToken != 20 || merged["c"].InputCostPerToken != 3 {
+		t.Errorf("Merge() = %v", merged)
+	}
+	if base["b"].InputCostPerToken != 2 {
+		t.Error("Merge() modified the base table")
+	}
+}
+
+func TestCacheRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CCL_CONFIG_DIR", dir)
+
+	if _, _, err := LoadCache(); !os.IsNotExist(err) {
+		t.Fatalf("LoadCache() without cache error = %v; want not exist", err)
+	}
+
+	table := Table{"claude-test": {InputCostPerToken: 1e-6, CacheReadCostPerToken: 1e-7}}
+	if err := SaveCache(table); err != nil {
+		t.Fatalf("SaveCache() error = %v", err)
+	}
+	if CachePath() != filepath.Join(dir, "pricing.json") {
+		t.Errorf("CachePath() = %s", CachePath())
+	}
+
+	loaded, fetchedAt, err := LoadCache()
+	if err != nil {
+		t.Fatalf("LoadCache() error = %v", err)
+	}
+	if loaded["claude-test"] != table["claude-test"] {
+		t.Errorf("LoadCache() = %v; want %v", loaded, table)
+	}
+	if fetchedAt.IsZero() {
+		t.Error("LoadCache() returned zero fetch time")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"claude-sonnet-4-20250514":                             "claude-sonnet-4",
+		"claude-3-5-haiku-latest":                              "claude-3-5-haiku",
+		"anthropic.claude-3-opus-20240229-v1:0":                "claude-3-opus",
+		"bedrock/us.anthropic.claude-3-5-sonnet-20241022-v2:0": "claude-3-5-sonnet",
+		"vertex_ai/claude-opus-4-1@20250805":                   "claude-opus-4-1",
+		"vertex_ai/claude-3-5-sonnet-v2@20241022":              "claude-3-5-sonnet-v2",
+		"openrouter/anthropic/claude-3.7-sonnet":               "claude-3-7-sonnet",
+		"Claude-Opus-4-20250514":                               "claude-opus-4",
+	}
+	for input, expected := range tests {
+		if got := Normalize(input); got != expected {
+			t.Errorf("Normalize(%q) = %q; want %q", input, got, expected)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	table := Table{
+		"claude-3-opus-20240229":                       {InputCostPerToken: 15},
+		"claude-opus-4-20250514":                       {InputCostPerToken: 15.1},
+		"claude-opus-4-1-20250805":                     {InputCostPerToken: 15.2},
+		"claude-3-5-sonnet-20240620":                   {InputCostPerToken: 3},
+		"claude-3-5-sonnet-20241022":                   {InputCostPerToken: 3.1},
+		"bedrock/anthropic.claude-3-5-sonnet-20241022": {InputCostPerToken: 99},
+		"claude-sonnet-4-20250514":                     {InputCostPerToken: 3.2},
+	}
+
+	tests := map[string]struct {
+		model string
+		key   string
+	}{
+		"exact key":             {"claude-opus-4-20250514", "claude-opus-4-20250514"},
+		"different date":        {"claude-opus-4-20250601", "claude-opus-4-20250514"},
+		"claude 3 is not 4":     {"claude-3-opus-20240229", "claude-3-opus-20240229"},
+		"longer version wins":   {"claude-opus-4-1-20250901", "claude-opus-4-1-20250805"},
+		"plain and newest wins": {"claude-3-5-sonnet", "claude-3-5-sonnet-20241022"},
+		"bedrock id":            {"us.anthropic.claude-sonnet-4-20250514-v1:0", "claude-sonnet-4-20250514"},
+		"vertex variant prefix": {"claude-3-5-sonnet-v2@20241022", "claude-3-5-sonnet-20241022"},
+		"unknown model":         {"<synthetic>", ""},
+		"no partial family":     {"claude-opus", ""},
+		"newer minor version":   {"claude-opus-4-6", ""},
+		"newer dated version":   {"claude-opus-4-6-20260101", ""},
+	}
+
+	// Repeat with fresh resolvers to catch any dependence on map order
+	for i := 0; i < 20; i++ {
+		r := NewResolver(table)
+		for name, tt := range tests {
+			key, price, ok := r.Resolve(tt.model)
+			if key != tt.key {
+				t.Fatalf("%s: Resolve(%q) key = %q; want %q", name, tt.model, key, tt.key)
+			}
+			if ok != (tt.key != "") || price != table[tt.key] {
+				t.Fatalf("%s: Resolve(%q) = %v, %v", name, tt.model, price, ok)
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package pricing
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+var (
+	// Release date suffix, e.g. -20250514 or Vertex AI's @20250514
+	dateSuffix = regexp.MustCompile(`[-@]\d{8}$`)
+	// Bedrock model version suffix, e.g. -v2:0 or :0
+	bedrockVersion = regexp.MustCompile(`(-v\d+)?:\d+$`)
+	// Provider variant of a model, e.g. Vertex AI's claude-3-5-sonnet-v2
+	variantSuffix = regexp.MustCompile(`-v\d+$`)
+)
+
+// Normalize reduces a model ID to its family and version by dropping
+// provider prefixes (bedrock/, vertex_ai/, us.anthropic., ...), Bedrock
+// version tags and release dates, e.g.
+// "bedrock/us.anthropic.claude-3-5-sonnet-20241022-v2:0" -> "claude-3-5-sonnet"
+func Normalize(model string) string {
+	m := strings.ToLower(strings.TrimSpace(model))
+	if i := strings.LastIndex(m, "/"); i >= 0 {
+		m = m[i+1:]
+	}
+	if i := strings.Index(m, "anthropic."); i >= 0 {
+		m = m[i+len("anthropic."):]
+	}
+	m = bedrockVersion.ReplaceAllString(m, "")
+	m = strings.TrimSuffix(m, "-latest")
+	m = dateSuffix.ReplaceAllString(m, "")
+	// Some providers spell versions with dots (claude-3.5-sonnet)
+	return strings.ReplaceAll(m, ".", "-")
+}
+
+// Resolver matches model IDs to price table keys. Matching is deterministic:
+//  1. an exact key
+//  2. a key with the same normalized family and version
+//  3. the same, ignoring a provider variant suffix such as -v2
+//
+// A newer version of a family (claude-opus-4-6) never falls back to an
+// older one's prices: it is reported as unpriced until the table has it.
+// When several keys share a normalized form, keys without a provider prefix
+// win, then the most recent release. Results are memoized; a Resolver is
+// not safe for concurrent use.
+type Resolver struct {
+	table      Table
+	normalized map[string]string // Normalized form -> preferred table key
+	cache      map[string]string
+}
+
+// NewResolver indexes a price table for lookups
+func NewResolver(t Table) *Resolver {
+	r := &Resolver{
+		table:      t,
+		normalized: make(map[string]string, len(t)),
+		cache:      make(map[string]string),
+	}
+	for key := range t {
+		n := Normalize(key)
+		if current, ok := r.normalized[n]; !ok || preferKey(key, current) {
+			r.normalized[n] = key
+		}
+	}
+	return r
+}
+
+// preferKey reports whether key a should be used over b for the same model
+func preferKey(a, b string) bool {
+	aPlain, bPlain := isPlainKey(a), isPlainKey(b)
+	if aPlain != bPlain {
+		return aPlain
+	}
+	// Later release dates sort higher
+	return a > b
+}
+
+// isPlainKey reports whether a key is an Anthropic API model ID without provider prefix
+func isPlainKey(key string) bool {
+	return !strings.Contains(key, "/") && !strings.Contains(key, "anthropic.")
+}
+
+// Table returns the underlying price table
+func (r *Resolver) Table() Table {
+	if r == nil {
+		return nil
+	}
+	return r.table
+}
+
+// Resolve returns the table key and prices for a model.
+// ok is false when no key matches; prices are zero then.
+func (r *Resolver) Resolve(model string) (key string, price ModelPricing, ok bool) {
+	if r == nil {
+		return "", ModelPricing{}, false // Prices not loaded
+	}
+
+	key, cached := r.cache[model]
+	if !cached {
+		key = r.match(model)
+		r.cache[model] = key
+	}
+	if key == "" {
+		return "", ModelPricing{}, false
+	}
+	return key, r.table[key], true
+}
+
+// match finds the table key for a model, or ""
+func (r *Resolver) match(model string) string {
+	if _, ok := r.table[model]; ok {
+		return model
+	}
+
+	n := Normalize(model)
+	if n == "" {
+		return ""
+	}
+	if key, ok := r.normalized[n]; ok {
+		return key
+	}
+	return r.normalized[variantSuffix.ReplaceAllString(n, "")]
+}
+
+// Cost calculates the cost of token usage for a model
+func (r *Resolver) Cost(usage transcript.Usage, model string) float64 {
+	_, price, _ := r.Resolve(model)
+	return price.Cost(usage)
+}
@@ -0,0 +1,311 @@
+// Package projects locates Claude Code session transcripts stored under
+// the Claude configuration directory.
+package projects
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Session represents a session transcript (.jsonl) inside a project directory
+type Session struct {
+	ModTime time.Time
+	Path    string
+	Project string // Decoded project path
+	Display string // Shortened project name, set by ShortenNames
+	Size    int64
+	Current bool // Whether the session belongs to the current directory
+}
+
+// ConfigDir returns the Claude configuration directory
+// following the same logic as Claude Code:
+// 1. CLAUDE_CONFIG_DIR environment variable
+// 2. XDG_CONFIG_HOME/claude
+// 3. ~/.claude (default)
+func ConfigDir() string {
+	// Check CLAUDE_CONFIG_DIR first
+	if configDir := os.Getenv("CLAUDE_CONFIG_DIR"); configDir != "" {
+		return configDir
+	}
+
+	// Check XDG_CONFIG_HOME
+	if xdgConfig := os.Getenv("XDG_CONFIG_HOME"); xdgConfig != "" {
+		return filepath.Join(xdgConfig, "claude")
+	}
+
+	// Default to ~/.claude
+	home := os.Getenv("HOME")
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".claude")
+}
+
+// Root returns the directory holding all project directories
+func Root() string {
+	configDir := ConfigDir()
+	if configDir == "" {
+		return ""
+	}
+	return filepath.Join(configDir, "projects")
+}
+
+// Dir returns the project directory for a working directory path
+func Dir(projectPath string) string {
+	root := Root()
+	if root == "" {
+		return ""
+	}
+	return filepath.Join(root, EncodePath(projectPath))
+}
+
+// EncodePath encodes a path into a project directory name
+func EncodePath(path string) string {
+	// Replace path separators and dots with dashes
+	encoded := strings.ReplaceAll(path, "/", "-")
+	encoded = strings.ReplaceAll(encoded, ".", "-")
+	// Claude Code keeps the leading dash
+	return encoded
+}
+
+// DecodePath reverses the encoding to get original path
+func DecodePath(encoded string) string {
+	// This is a simple approximation - we can't perfectly reverse it
+	// but we can make it more readable
+
+	// For common patterns, try to reconstruct the original path
+	// Example: -Users-sixeight--config-claude -> /Users/sixeight/.config/claude
+	decoded := encoded
+
+	// Handle leading slash (paths usually start with /)
+	if strings.HasPrefix(decoded, "-") {
+		decoded = "/" + decoded[1:]
+	}
+
+	// Replace remaining dashes with slashes
+	decoded = strings.ReplaceAll(decoded, "-", "/")
+
+	// Try to fix common patterns like /.config
+	decoded = strings.ReplaceAll(decoded, "//config", "/.config")
+	decoded = strings.ReplaceAll(decoded, "//ssh", "/.ssh")
+	decoded = strings.ReplaceAll(decoded, "//local", "/.local")
+	decoded = strings.ReplaceAll(decoded, "//cache", "/.cache")
+
+	return decoded
+}
+
+// IsEmptySession checks if a session file contains no user/assistant messages
+func IsEmptySession(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return true // If we can't open it, treat as empty
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	for {
+		var entry struct {
+			Type string `json:"type"`
+		}
+		if err := decoder.Decode(&entry); err != nil {
+			break
+		}
+		// Check if this entry is a user or assistant message
+		if entry.Type == "user" || entry.Type == "assistant" {
+			return false // Found a message, not empty
+		}
+	}
+	return true // No messages found
+}
+
+// LatestSession returns the most recently modified non-empty session
+// for a working directory path, or "" if there is none
+func LatestSession(projectPath string) string {
+	sessions, err := ForProject(projectPath)
+	if err != nil || len(sessions) == 0 {
+		return ""
+	}
+	SortByModTime(sessions)
+	return sessions[0].Path
+}
+
+// LatestSessionIn returns the most recently modified non-empty session
+// in a project directory, or "" if there is none
+func LatestSessionIn(projectDir string) string {
+	sessions, err := sessionsInDir(projectDir, DecodePath(filepath.Base(projectDir)))
+	if err != nil || len(sessions) == 0 {
+		return ""
+	}
+	SortByModTime(sessions)
+	return sessions[0].Path
+}
+
+// ForProject collects the non-empty sessions of a single working directory path
+func ForProject(projectPath string) ([]Session, error) {
+	projectDir := Dir(projectPath)
+	if projectDir == "" {
+		return nil, fmt.Errorf("could not determine Claude config directory")
+	}
+
+	sessions, err := sessionsInDir(projectDir, projectPath)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = true
+	}
+	return sessions, nil
+}
+
+// All collects the non-empty sessions from all project directories
+func All() ([]Session, error) {
+	projectsDir := Root()
+	if projectsDir == "" {
+		return nil, fmt.Errorf("could not determine Claude config directory")
+	}
+
+	entries, err := os.ReadDir(projectsDir)
+	if err != nil {
+		return nil, fmt.Errorf("reading projects directory: %w", err)
+	}
+
+	var sessions []Session
+
+	// Get current working directory for comparison
+	cwd, _ := os.Getwd()
+	currentEncoded := EncodePath(cwd)
+
+	// Find all session files
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		projectDir := filepath.Join(projectsDir, entry.Name())
+		files, err := sessionsInDir(projectDir, DecodePath(entry.Name()))
+		if err != nil {
+			continue
+		}
+		for i := range files {
+			files[i].Current = entry.Name() == currentEncoded
+		}
+		sessions = append(sessions, files...)
+	}
+
+	return sessions, nil
+}
+
+// sessionsInDir collects non-empty JSONL files from a single project directory
+func sessionsInDir(projectDir, projectPath string) ([]Session, error) {
+	files, err := os.ReadDir(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, len(files))
+
+	// Look for JSONL files
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".jsonl") {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+
+		fullPath := filepath.Join(projectDir, file.Name())
+		// Skip empty session files
+		if IsEmptySession(fullPath) {
+			continue
+		}
+
+		sessions = append(sessions, Session{
+			Path:    fullPath,
+			Project: projectPath,
+			ModTime: info.ModTime(),
+			Size:    info.Size(),
+		})
+	}
+
+	return sessions, nil
+}
+
+// SortByModTime sorts sessions by modification time (most recent first)
+func SortByModTime(sessions []Session) {
+	for i := 0; i < len(sessions); i++ {
+		for j := i + 1; j < len(sessions); j++ {
+			if sessions[j].ModTime.After(sessions[i].ModTime) {
+				sessions[i], sessions[j] = sessions[j], sessions[i]
+			}
+		}
+	}
+}
+
+// ShortenNames generates shortened display names for sessions.
+// It shows only the last directory name, but includes parent directories when there are duplicates.
+func ShortenNames(sessions []Session) {
+	// First pass: count occurrences of last directory names
+	lastDirCount := make(map[string]int)
+	lastDirOnly := make([]string, len(sessions))
+
+	for i, s := range sessions {
+		parts := strings.Split(s.Project, "/")
+		if len(parts) > 0 {
+			lastDir := parts[len(parts)-1]
+			lastDirOnly[i] = lastDir
+			lastDirCount[lastDir]++
+		}
+	}
+
+	// Second pass: generate display names
+	for i, s := range sessions {
+		parts := strings.Split(s.Project, "/")
+		if len(parts) == 0 {
+			sessions[i].Display = s.Project
+			continue
+		}
+
+		lastDir := parts[len(parts)-1]
+
+		// If no duplicates, use only the last directory
+		if lastDirCount[lastDir] == 1 {
+			sessions[i].Display = lastDir
+			continue
+		}
+
+		// For duplicates, find the minimum number of parent directories needed
+		// to make each path unique within the duplicate set
+		displayName := lastDir
+
+		// Keep adding parent directories until we have a unique display name
+		for j := len(parts) - 2; j >= 0; j-- {
+			displayName = parts[j] + "/" + displayName
+
+			// Check if this display name is unique among all sessions
+			isUnique := true
+			for k, other := range sessions {
+				if k == i {
+					continue
+				}
+				// Only check against other sessions with the same last directory
+				if lastDirOnly[k] == lastDir {
+					if strings.HasSuffix(other.Project, displayName) {
+						isUnique = false
+						break
+					}
+				}
+			}
+
+			if isUnique {
+				break
+			}
+		}
+
+		sessions[i].Display = displayName
+	}
+}
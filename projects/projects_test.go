@@ -1,12 +1,13 @@
-package main
+package projects
 
 import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
-func TestIsEmptyProjectFile(t *testing.T) {
+func TestIsEmptySession(t *testing.T) {
 	// Create temporary directory for test files
 	tmpDir, err := os.MkdirTemp("", "ccl-test-*")
 	if err != nil {
@@ -68,23 +69,23 @@ func TestIsEmptyProjectFile(t *testing.T) {
 			}
 
 			// Test the function
-			result := isEmptyProjectFile(testFile)
+			result := IsEmptySession(testFile)
 			if result != tc.expected {
-				t.Errorf("isEmptyProjectFile(%s) = %v, want %v", tc.filename, result, tc.expected)
+				t.Errorf("IsEmptySession(%s) = %v, want %v", tc.filename, result, tc.expected)
 			}
 		})
 	}
 
 	// Test non-existent file
 	t.Run("non-existent file", func(t *testing.T) {
-		result := isEmptyProjectFile(filepath.Join(tmpDir, "does-not-exist.jsonl"))
+		result := IsEmptySession(filepath.Join(tmpDir, "does-not-exist.jsonl"))
 		if !result {
-			t.Error("isEmptyProjectFile(non-existent) = false, want true")
+			t.Error("IsEmptySession(non-existent) = false, want true")
 		}
 	})
 }
 
-func TestProjectEncodeDirectoryPath(t *testing.T) {
+func TestEncodePath(t *testing.T) {
 	tests := map[string]struct {
 		input    string
 		expected string
@@ -109,15 +110,15 @@ func TestProjectEncodeDirectoryPath(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			result := encodeDirectoryPath(tc.input)
+			result := EncodePath(tc.input)
 			if result != tc.expected {
-				t.Errorf("encodeDirectoryPath(%q) = %q, want %q", tc.input, result, tc.expected)
+				t.Errorf("EncodePath(%q) = %q, want %q", tc.input, result, tc.expected)
 			}
 		})
 	}
 }
 
-func TestProjectDecodeDirectoryPath(t *testing.T) {
+func TestDecodePath(t *testing.T) {
 	tests := map[string]struct {
 		input    string
 		expected string
@@ -154,10 +155,61 @@ func TestProjectDecodeDirectoryPath(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			result := decodeDirectoryPath(tc.input)
+			result := DecodePath(tc.input)
 			if result != tc.expected {
-				t.Errorf("decodeDirectoryPath(%q) = %q, want %q", tc.input, result, tc.expected)
+				t.Errorf("DecodePath(%q) = %q, want %q", tc.input, result, tc.expected)
 			}
 		})
 	}
 }
+
+func TestAllAndLatestSession(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("CLAUDE_CONFIG_DIR", tempDir)
+
+	projectPath := "/test/project"
+	projectDir := filepath.Join(tempDir, "projects", EncodePath(projectPath))
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("Failed to create project directory: %v", err)
+	}
+
+	message := `{"type":"user","message":{"role":"user","content":"Hello"}}`
+	files := map[string]string{
+		"old.jsonl":   message,
+		"new.jsonl":   message,
+		"empty.jsonl": `{"type":"summary","summary":"nothing"}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(projectDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	// Make the newest file clearly newer than the others
+	now := time.Now()
+	for name, age := range map[string]time.Duration{"old.jsonl": 2 * time.Hour, "empty.jsonl": time.Minute, "new.jsonl": 0} {
+		modTime := now.Add(-age)
+		if err := os.Chtimes(filepath.Join(projectDir, name), modTime, modTime); err != nil {
+			t.Fatalf("Failed to set mtime: %v", err)
+		}
+	}
+
+	sessions, err := All()
+	if err != nil {
+		t.Fatalf("All() error = %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("All() returned %d sessions; want 2 (empty skipped)", len(sessions))
+	}
+	for _, s := range sessions {
+		if s.Project != projectPath {
+			t.Errorf("session.Project = %q; want %q", s.Project, projectPath)
+		}
+	}
+
+	if latest := LatestSession(projectPath); filepath.Base(latest) != "new.jsonl" {
+		t.Errorf("LatestSession() = %q; want new.jsonl", latest)
+	}
+	if latest := LatestSession("/nonexistent"); latest != "" {
+		t.Errorf("LatestSession(nonexistent) = %q; want empty", latest)
+	}
+}
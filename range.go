@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+// entryRange limits the log to a stretch of the conversation by time,
+// by turn (a user prompt and everything up to the next one) or by UUID
+type entryRange struct {
+	since    time.Time
+	until    time.Time
+	first    int // Only the first N turns
+	last     int // Only the last N turns
+	fromUUID string
+	toUUID   string
+}
+
+// parseEntryRange builds a range from the log flags, resolving relative
+// times against now
+func parseEntryRange(since, until string, first, last int, fromUUID, toUUID string, now time.Time) (entryRange, error) {
+	r := entryRange{first: first, last: last, fromUUID: fromUUID, toUUID: toUUID}
+	if first < 0 || last < 0 {
+		return r, fmt.Errorf("--first and --last must not be negative")
+	}
+
+	var err error
+	if since != "" {
+		if r.since, err = parseTimeBound(since, now); err != nil {
+			return r, fmt.Errorf("invalid --since: %w", err)
+		}
+	}
+	if until != "" {
+		if r.until, err = parseTimeBound(until, now); err != nil {
+			return r, fmt.Errorf("invalid --until: %w", err)
+		}
+		// A date runs to the end of that day
+		if _, err := time.Parse("2006-01-02", until); err == nil {
+			r.until = r.until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+	}
+	return r, nil
+}
+
+// parseTimeBound parses an RFC3339 time, a local date (2006-01-02), or a
+// duration before now such as 2h, 90m or 3d
+func parseTimeBound(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is neither a time (RFC3339, 2006-01-02) nor a duration (2h, 3d)", s)
+}
+
+// isZero reports whether the range lets every entry through
+func (r entryRange) isZero() bool {
+	return r == entryRange{}
+}
+
+// rangeFilter applies a range to entries seen one at a time, in order.
+// The last turns can only be known at the end; see lastTurns and turnBuffer.
+type rangeFilter struct {
+	entryRange
+	turns   int  // User prompts seen
+	started bool // The from UUID was seen
+	sawTo   bool // The to UUID was seen
+	ended   bool // The to UUID or the first turns were passed
+}
+
+// filter starts applying the range to a new sequence of entries
+func (r entryRange) filter() *rangeFilter {
+	return &rangeFilter{entryRange: r, started: r.fromUUID == ""}
+}
+
+// allow reports whether an entry is within the range
+func (f *rangeFilter) allow(entry *transcript.Entry) bool {
+	if f.toUUID != "" && entry.UUID == f.toUUID {
+		f.sawTo = true
+	}
+	if f.ended {
+		return false
+	}
+	if !f.started {
+		if entry.UUID != f.fromUUID {
+			return false
+		}
+		f.started = true
+	}
+	if f.toUUID != "" && entry.UUID == f.toUUID {
+		f.ended = true
+	}
+
+	if entry.IsPrompt() {
+		f.turns++
+		if f.first > 0 && f.turns > f.first {
+			f.ended = true
+			return false
+		}
+	}
+
+	if !f.since.IsZero() || !f.until.IsZero() {
+		t, ok := entry.Time()
+		if !ok || (!f.since.IsZero() && t.Before(f.since)) || (!f.until.IsZero() && t.After(f.until)) {
+			return false
+		}
+	}
+	return true
+}
+
+// unknownUUID reports a from or to UUID not among the entries seen
+func (f *rangeFilter) unknownUUID() error {
+	switch {
+	case !f.started:
+		return fmt.Errorf("unknown entry UUID %s", f.fromUUID)
+	case f.toUUID != "" && !f.sawTo:
+		return fmt.Errorf("unknown entry UUID %s", f.toUUID)
+	}
+	return nil
+}
+
+// apply filters a whole conversation by the range
+func (r entryRange) apply(entries []*transcript.Entry) []*transcript.Entry {
+	if r.isZero() {
+		return entries
+	}
+	f := r.filter()
+	var allowed []*transcript.Entry
+	for _, entry := range entries {
+		if f.allow(entry) {
+			allowed = append(allowed, entry)
+		}
+	}
+	return lastTurns(allowed, r.last)
+}
+
+// checkUUIDs reports a from or to UUID that none of the entries has
+func (r entryRange) checkUUIDs(entries []*transcript.Entry) error {
+	f := r.filter()
+	for _, entry := range entries {
+		f.allow(entry)
+	}
+	return f.unknownUUID()
+}
+
+// lastTurns returns the entries of the last n turns; n of zero keeps all
+func lastTurns(entries []*transcript.Entry, n int) []*transcript.Entry {
+	if n <= 0 {
+		return entries
+	}
+	turns := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].IsPrompt() {
+			turns++
+			if turns == n {
+				return entries[i:]
+			}
+		}
+	}
+	return entries
+}
+
+// turnBuffer keeps the entries of the last n turns of a stream
+type turnBuffer struct {
+	n       int
+	entries []*transcript.Entry
+	starts  []int // Index in entries where each buffered turn starts
+}
+
+// add appends an entry, dropping the oldest turn once more than n are held
+func (b *turnBuffer) add(entry *transcript.Entry) {
+	if entry.IsPrompt() {
+		b.starts = append(b.starts, len(b.entries))
+		if len(b.starts) > b.n {
+			drop := b.starts[1]
+			b.entries = append(b.entries[:0], b.entries[drop:]...)
+			b.starts = b.starts[1:]
+			for i := range b.starts {
+				b.starts[i] -= drop
+			}
+		}
+	}
+	b.entries = append(b.entries, entry)
+}
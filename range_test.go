@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2025, 6, 22, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		input    string
+		expected time.Time
+		wantErr  bool
+	}{
+		"rfc3339":  {"2025-06-22T09:30:00Z", time.Date(2025, 6, 22, 9, 30, 0, 0, time.UTC), false},
+		"hours":    {"2h", now.Add(-2 * time.Hour), false},
+		"compound": {"1h30m", now.Add(-90 * time.Minute), false},
+		"days":     {"3d", now.AddDate(0, 0, -3), false},
+		"date":     {"2025-06-01", time.Date(2025, 6, 1, 0, 0, 0, 0, time.Local), false},
+		"negative": {"-2h", time.Time{}, true},
+		"garbage":  {"yesterday", time.Time{}, true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseTimeBound(tt.input, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTimeBound(%q) error = %v; wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !got.Equal(tt.expected) {
+				t.Errorf("parseTimeBound(%q) = %v; want %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEntryRange(t *testing.T) {
+	// Three turns: u1 a1 t1 | u2 a2 c1 c2 | u3 a3, where the slash command
+	// c1/c2 is not a prompt of its own
+	input := strings.Join([]string{
+		`{"type":"user","uuid":"u1","timestamp":"2025-06-22T09:00:00Z","message":{"role":"user","content":"one"}}`,
+		`{"type":"assistant","uuid":"a1","timestamp":"2025-06-22T09:00:05Z","message":{"content":[{"type":"tool_use","id":"x","name":"Bash","input":{}}]}}`,
+		`{"type":"user","uuid":"t1","timestamp":"2025-06-22T09:00:06Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"x","content":"ok"}]}}`,
+		`{"type":"user","uuid":"u2","timestamp":"2025-06-22T10:00:00Z","message":{"role":"user","content":"two"}}`,
+		`{"type":"assistant","uuid":"a2","timestamp":"2025-06-22T10:00:05Z","message":{"content":"done"}}`,
+		`{"type":"user","uuid":"c1","isMeta":true,"timestamp":"2025-06-22T10:30:00Z","message":{"role":"user","content":"Caveat: local commands"}}`,
+		`{"type":"user","uuid":"c2","timestamp":"2025-06-22T10:30:00Z","message":{"role":"user","content":"<command-name>/cost</command-name>"}}`,
+		`{"type":"user","uuid":"u3","timestamp":"2025-06-22T11:00:00Z","message":{"role":"user","content":"three"}}`,
+		`{"type":"assistant","uuid":"a3","timestamp":"2025-06-22T11:00:05Z","message":{"content":"done"}}`,
+	}, "\n")
+	entries, err := transcript.ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	at := func(s string) time.Time {
+		t, _ := time.Parse(time.RFC3339, s)
+		return t
+	}
+
+	tests := map[string]struct {
+		rng      entryRange
+		expected string
+	}{
+		"everything":        {entryRange{}, "u1 a1 t1 u2 a2 c1 c2 u3 a3"},
+		"first turn":        {entryRange{first: 1}, "u1 a1 t1"},
+		"last two turns":    {entryRange{last: 2}, "u2 a2 c1 c2 u3 a3"},
+		"since":             {entryRange{since: at("2025-06-22T10:00:05Z")}, "a2 c1 c2 u3 a3"},
+		"until":             {entryRange{until: at("2025-06-22T09:30:00Z")}, "u1 a1 t1"},
+		"uuid range":        {entryRange{fromUUID: "t1", toUUID: "u2"}, "t1 u2"},
+		"from and last":     {entryRange{fromUUID: "a1", last: 1}, "u3 a3"},
+		"unknown from uuid": {entryRange{fromUUID: "nope"}, ""},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var uuids []string
+			for _, entry := range tt.rng.apply(entries) {
+				uuids = append(uuids, entry.UUID)
+			}
+			if got := strings.Join(uuids, " "); got != tt.expected {
+				t.Errorf("apply() = %q; want %q", got, tt.expected)
+			}
+
+			// Streaming keeps the same entries
+			f := tt.rng.filter()
+			buffer := &turnBuffer{n: tt.rng.last}
+			uuids = nil
+			for _, entry := range entries {
+				if !f.allow(entry) {
+					continue
+				}
+				if tt.rng.last > 0 {
+					buffer.add(entry)
+				} else {
+					uuids = append(uuids, entry.UUID)
+				}
+			}
+			for _, entry := range buffer.entries {
+				uuids = append(uuids, entry.UUID)
+			}
+			if got := strings.Join(uuids, " "); got != tt.expected {
+				t.Errorf("streaming = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseEntryRangeUntilDate(t *testing.T) {
+	now := time.Date(2025, 6, 30, 12, 0, 0, 0, time.Local)
+	r, err := parseEntryRange("2025-06-22", "2025-06-22", 0, 0, "", "", now)
+	if err != nil {
+		t.Fatalf("parseEntryRange() error = %v", err)
+	}
+	f := r.filter()
+	for _, tt := range []struct {
+		at   time.Time
+		want bool
+	}{
+		{time.Date(2025, 6, 21, 23, 59, 59, 0, time.Local), false},
+		{time.Date(2025, 6, 22, 0, 0, 0, 0, time.Local), true},
+		{time.Date(2025, 6, 22, 23, 59, 59, 0, time.Local), true},
+		{time.Date(2025, 6, 23, 0, 0, 0, 0, time.Local), false},
+	} {
+		entry := &transcript.Entry{Type: "assistant", Timestamp: tt.at.Format(time.RFC3339Nano)}
+		if got := f.allow(entry); got != tt.want {
+			t.Errorf("allow(%v) = %v; want %v", tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestEntryRangeUnknownUUID(t *testing.T) {
+	input := strings.Join([]string{
+		`{"type":"user","uuid":"u1","message":{"role":"user","content":"one"}}`,
+		`{"type":"assistant","uuid":"a1","message":{"content":"done"}}`,
+		`{"type":"user","uuid":"u2","message":{"role":"user","content":"two"}}`,
+	}, "\n")
+	entries, err := transcript.ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	tests := map[string]struct {
+		rng     entryRange
+		wantErr string
+	}{
+		"no uuids":            {entryRange{}, ""},
+		"known uuids":         {entryRange{fromUUID: "a1", toUUID: "u2"}, ""},
+		"to after first ends": {entryRange{first: 1, toUUID: "u2"}, ""},
+		"unknown from":        {entryRange{fromUUID: "nope"}, "unknown entry UUID nope"},
+		"unknown to":          {entryRange{fromUUID: "u1", toUUID: "nope"}, "unknown entry UUID nope"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tt.rng.checkUUIDs(entries)
+			if got := fmt.Sprint(err); (tt.wantErr == "" && err != nil) || (tt.wantErr != "" && got != tt.wantErr) {
+				t.Errorf("checkUUIDs() error = %v; want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("piped input", func(t *testing.T) {
+		origCfg, origLogConfig, origOutput := cfg, logConfig, output
+		defer func() {
+			cfg, logConfig, output = origCfg, origLogConfig, origOutput
+		}()
+		cfg = Config{NoColor: true, OutputFormat: "text"}
+		logConfig = LogConfig{rng: entryRange{toUUID: "nope"}}
+		output = io.Discard
+
+		if err := processStreaming(strings.NewReader(input)); err == nil || err.Error() != "unknown entry UUID nope" {
+			t.Errorf("processStreaming() error = %v; want unknown entry UUID nope", err)
+		}
+	})
+}
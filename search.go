@@ -0,0 +1,290 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Sixeight/ccl/projects"
+	"github.com/Sixeight/ccl/transcript"
+)
+
+// Runes of context shown on each side of a match
+const searchSnippetContext = 60
+
+// searchField is a piece of searchable text within an entry
+type searchField struct {
+	Role string // user, assistant or tool
+	Tool string // Tool name for tool inputs and results
+	Text string
+}
+
+// searchMatch is a field that matched the query
+type searchMatch struct {
+	Path      string `json:"path"`
+	Project   string `json:"project"`
+	UUID      string `json:"uuid,omitempty"`
+	Timestamp string `json:"timestamp,omitempty"`
+	Role      string `json:"role"`
+	Tool      string `json:"tool,omitempty"`
+	Snippet   string `json:"snippet"`
+}
+
+// SearchConfig holds flags specific to the search command
+type SearchConfig struct {
+	regex      bool
+	ignoreCase bool
+	current    bool
+	jsonFlag   bool
+	limit      int
+}
+
+var searchConfig SearchConfig
+
+// compileSearchQuery builds the matcher for a query, literal unless regex is set
+func compileSearchQuery(query string, regex, ignoreCase bool) (*regexp.Regexp, error) {
+	if !regex {
+		query = regexp.QuoteMeta(query)
+	}
+	if ignoreCase {
+		query = "(?i)" + query
+	}
+	re, err := regexp.Compile(query)
+	if err != nil {
+		return nil, err
+	}
+	// It would match every entry of every session
+	if re.MatchString("") {
+		return nil, fmt.Errorf("the query matches empty text")
+	}
+	return re, nil
+}
+
+// searchFields extracts the searchable text of an entry
+func searchFields(entry *transcript.Entry, tools transcript.ToolIndex) []searchField {
+	if entry.Message == nil {
+		return nil
+	}
+
+	var fields []searchField
+	for i := range entry.Message.Content {
+		block := &entry.Message.Content[i]
+		switch block.Type {
+		case transcript.BlockText:
+			fields = append(fields, searchField{Role: entry.Type, Text: block.Text})
+		case transcript.BlockToolUse:
+			fields = append(fields, searchField{Role: "assistant", Tool: block.Name, Text: toolInputText(block.InputFields())})
+		case transcript.BlockToolResult:
+			fields = append(fields, searchField{Role: "tool", Tool: tools.Name(block.ToolUseID), Text: block.Content.Text()})
+		}
+	}
+	return fields
+}
+
+// toolInputText flattens tool input values into searchable text, in key order
+func toolInputText(input map[string]interface{}) string {
+	keys := make([]string, 0, len(input))
+	for key := range input {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		switch v := input[key].(type) {
+		case string:
+			values = append(values, v)
+		default:
+			if data, err := json.Marshal(v); err == nil {
+				values = append(values, string(data))
+			}
+		}
+	}
+	return strings.Join(values, "\n")
+}
+
+// shouldSearchField applies the --role and --tool filters to a field.
+// As in the log command, tool filters take precedence over role filters.
+func shouldSearchField(field searchField, filter entryFilter) bool {
+	if filter.hasToolFilters() {
+		return field.Tool != "" && filter.matchTool(field.Tool)
+	}
+	return filter.matchRole(field.Role, nil)
+}
+
+// searchEntries returns the matching fields of a session's entries
+func searchEntries(entries []*transcript.Entry, pattern *regexp.Regexp, filter entryFilter, session projects.Session) []searchMatch {
+	tools := transcript.NewToolIndex(entries)
+
+	var matches []searchMatch
+	for _, entry := range entries {
+		for _, field := range searchFields(entry, tools) {
+			if !shouldSearchField(field, filter) {
+				continue
+			}
+			loc := pattern.FindStringIndex(field.Text)
+			if loc == nil {
+				continue
+			}
+			matches = append(matches, searchMatch{
+				Path:      session.Path,
+				Project:   session.Project,
+				UUID:      entry.UUID,
+				Timestamp: entry.Timestamp,
+				Role:      field.Role,
+				Tool:      field.Tool,
+				Snippet:   searchSnippet(field.Text, loc, searchSnippetContext),
+			})
+		}
+	}
+	return matches
+}
+
+// searchSnippet returns the line around a match, shortened to context runes
+// on each side, with the match highlighted
+func searchSnippet(text string, loc []int, context int) string {
+	start, end := loc[0], loc[1]
+
+	lineStart := strings.LastIndexByte(text[:start], '\n') + 1
+	lineEnd := len(text)
+	if idx := strings.IndexByte(text[end:], '\n'); idx >= 0 {
+		lineEnd = end + idx
+	}
+
+	before := []rune(strings.TrimLeft(text[lineStart:start], " \t"))
+	prefix := ""
+	if len(before) > context {
+		before = before[len(before)-context:]
+		prefix = "..."
+	}
+	after := []rune(text[end:lineEnd])
+	suffix := ""
+	if len(after) > context {
+		after = after[:context]
+		suffix = "..."
+	}
+
+	match := strings.ReplaceAll(text[start:end], "\n", " ")
+	snippet := prefix + string(before) + color(colorRed+colorBold) + match + color(colorReset) + string(after) + suffix
+	return strings.ReplaceAll(snippet, "\t", " ")
+}
+
+// runSearchCommand runs the search subcommand
+func runSearchCommand(args []string) {
+	searchCmd := flag.NewFlagSet("search", flag.ExitOnError)
+	searchCmd.BoolVar(&searchConfig.regex, "regex", false, "treat the query as a regular expression")
+	searchCmd.BoolVar(&searchConfig.ignoreCase, "i", false, "ignore case")
+	searchCmd.StringVar(&cfg.Role, "role", "", "search only these roles (user,assistant,tool)")
+	searchCmd.StringVar(&cfg.ToolFilter, "tool", "", "search only inputs and results of these tools (supports glob)")
+	searchCmd.StringVar(&cfg.ToolExclude, "tool-exclude", "", "exclude tools by name (supports glob)")
+	searchCmd.BoolVar(&searchConfig.current, "current", false, "search only the current directory's sessions")
+	searchCmd.IntVar(&searchConfig.limit, "limit", 0, "stop after N matches (0 for no limit)")
+	searchCmd.BoolVar(&searchConfig.jsonFlag, "json", false, "output matches as JSON")
+	searchCmd.BoolVar(&cfg.NoColor, "no-color", false, "disable color output")
+
+	searchCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ccl search [options] <query>\n\n")
+		fmt.Fprintf(os.Stderr, "Search prompts, responses, tool inputs and tool results of all sessions.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		searchCmd.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  ccl search migration\n")
+		fmt.Fprintf(os.Stderr, "  ccl search --tool Bash -i 'rake db:migrate'\n")
+		fmt.Fprintf(os.Stderr, "  ccl search --regex --role user 'TODO|FIXME'\n")
+	}
+
+	if err := searchCmd.Parse(args); err != nil {
+		return
+	}
+	query := strings.Join(searchCmd.Args(), " ")
+	if strings.TrimSpace(query) == "" {
+		searchCmd.Usage()
+		os.Exit(1)
+	}
+	if searchConfig.jsonFlag {
+		cfg.NoColor = true
+	}
+
+	pattern, err := compileSearchQuery(query, searchConfig.regex, searchConfig.ignoreCase)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid query: %v\n", err)
+		os.Exit(1)
+	}
+
+	filter := cfgEntryFilter()
+
+	var sessions []projects.Session
+	if searchConfig.current {
+		cwd, _ := os.Getwd()
+		sessions, err = projects.ForProject(cwd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
+		}
+	} else {
+		sessions = collectAllProjectFiles()
+	}
+	projects.SortByModTime(sessions)
+	projects.ShortenNames(sessions)
+
+	var allMatches []searchMatch
+	for _, session := range sessions {
+		entries, err := transcript.ReadFile(session.Path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", session.Path, err)
+			continue
+		}
+
+		matches := searchEntries(entries, pattern, filter, session)
+		if searchConfig.limit > 0 && len(allMatches)+len(matches) > searchConfig.limit {
+			matches = matches[:searchConfig.limit-len(allMatches)]
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		allMatches = append(allMatches, matches...)
+
+		if !searchConfig.jsonFlag {
+			displaySearchMatches(session, matches)
+		}
+		if searchConfig.limit > 0 && len(allMatches) >= searchConfig.limit {
+			break
+		}
+	}
+
+	if searchConfig.jsonFlag {
+		if allMatches == nil {
+			allMatches = []searchMatch{}
+		}
+		jsonData, _ := json.MarshalIndent(allMatches, "", "  ")
+		fmt.Fprintln(output, string(jsonData))
+	}
+}
+
+// displaySearchMatches prints the matches of one session under a header
+func displaySearchMatches(session projects.Session, matches []searchMatch) {
+	fmt.Fprintf(output, "%s%s%s %s(%s)%s\n",
+		color(colorPurple+colorBold), session.Path, color(colorReset),
+		color(colorGray), session.Display, color(colorReset))
+
+	for _, m := range matches {
+		timeStr := m.Timestamp
+		if t, err := time.Parse(time.RFC3339, m.Timestamp); err == nil {
+			timeStr = t.Local().Format("2006-01-02 15:04:05")
+		}
+		label := m.Role
+		if m.Tool != "" {
+			label += ":" + m.Tool
+		}
+		fmt.Fprintf(output, "  %s[%s]%s %s%-16s%s %s\n",
+			color(colorGray), timeStr, color(colorReset),
+			color(colorCyan), label, color(colorReset),
+			m.Snippet)
+	}
+	fmt.Fprintln(output)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Sixeight/ccl/projects"
+	"github.com/Sixeight/ccl/transcript"
+)
+
+func TestSearchSnippet(t *testing.T) {
+	origCfg := cfg
+	defer func() { cfg = origCfg }()
+	cfg.NoColor = true
+
+	tests := map[string]struct {
+		text     string
+		query    string
+		context  int
+		expected string
+	}{
+		"single line": {
+			text:     "run the migration now",
+			query:    "migration",
+			context:  60,
+			expected: "run the migration now",
+		},
+		"only matching line": {
+			text:     "first\n  bundle exec rake db:migrate\nlast",
+			query:    "rake",
+			context:  60,
+			expected: "bundle exec rake db:migrate",
+		},
+		"long line is shortened": {
+			text:     "aaaaaaaaaa needle bbbbbbbbbb",
+			query:    "needle",
+			context:  4,
+			expected: "...aaa needle bbb...",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			loc := []int{strings.Index(tt.text, tt.query), strings.Index(tt.text, tt.query) + len(tt.query)}
+			if got := searchSnippet(tt.text, loc, tt.context); got != tt.expected {
+				t.Errorf("searchSnippet() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSearchEntries(t *testing.T) {
+	origCfg := cfg
+	defer func() { cfg = origCfg }()
+	cfg.NoColor = true
+
+	input := strings.Join([]string{
+		`{"type":"user","uuid":"u1","message":{"content":"Please run the Migration"}}`,
+		`{"type":"assistant","uuid":"a1","message":{"content":[{"type":"text","text":"Running the migration"},{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"rake db:migrate"}}]}}`,
+		`{"type":"user","uuid":"u2","message":{"content":[{"type":"tool_result","tool_use_id":"t1","content":"== CreateUsers: migrated"}]}}`,
+	}, "\n")
+	entries, err := transcript.ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	session := projects.Session{Path: "/tmp/s.jsonl", Project: "/work/app"}
+
+	tests := map[string]struct {
+		query      string
+		regex      bool
+		ignoreCase bool
+		role       string
+		tool       string
+		expected   []string // role or role:tool of each match
+	}{
+		"literal is case sensitive": {
+			query:    "migrat",
+			expected: []string{"assistant", "assistant:Bash", "tool:Bash"},
+		},
+		"ignore case": {
+			query:      "migrat",
+			ignoreCase: true,
+			expected:   []string{"user", "assistant", "assistant:Bash", "tool:Bash"},
+		},
+		"regex": {
+			query:    `db:\w+`,
+			regex:    true,
+			expected: []string{"assistant:Bash"},
+		},
+		"role filter": {
+			query:      "migrat",
+			ignoreCase: true,
+			role:       "user,tool",
+			expected:   []string{"user", "tool:Bash"},
+		},
+		"tool filter": {
+			query:    "migrat",
+			tool:     "Ba*",
+			expected: []string{"assistant:Bash", "tool:Bash"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			filter := newEntryFilter(tt.role, tt.tool, "")
+
+			pattern, err := compileSearchQuery(tt.query, tt.regex, tt.ignoreCase)
+			if err != nil {
+				t.Fatalf("compileSearchQuery() error = %v", err)
+			}
+			var got []string
+			for _, m := range searchEntries(entries, pattern, filter, session) {
+				label := m.Role
+				if m.Tool != "" {
+					label += ":" + m.Tool
+				}
+				got = append(got, label)
+				if m.Path != session.Path {
+					t.Errorf("match path = %s; want %s", m.Path, session.Path)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("matches = %v; want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCompileSearchQueryEmpty(t *testing.T) {
+	for _, tt := range []struct {
+		query string
+		regex bool
+	}{
+		{"", false},
+		{"", true},
+		{"x*", true},
+		{"a|", true},
+	} {
+		if _, err := compileSearchQuery(tt.query, tt.regex, false); err == nil {
+			t.Errorf("compileSearchQuery(%q, regex=%v) succeeded; want an error for a query matching everything", tt.query, tt.regex)
+		}
+	}
+}
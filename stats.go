@@ -0,0 +1,337 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+// sessionStats summarizes the activity and spend of a single session
+type sessionStats struct {
+	Start           time.Time                   `json:"start"`
+	End             time.Time                   `json:"end"`
+	ToolCalls       map[string]int              `json:"tool_calls"`
+	ToolErrors      map[string]int              `json:"tool_errors"`
+	ModelUsage      map[string]transcript.Usage `json:"-"`
+	Models          []modelCost                 `json:"models"`
+	Cost            float64                     `json:"cost_usd"`
+	Usage           transcript.Usage            `json:"usage"`
+	DurationSeconds float64                     `json:"duration_seconds"`
+	UserPrompts     int                         `json:"user_prompts"`
+	AssistantTurns  int                         `json:"assistant_turns"`
+	ToolResults     int                         `json:"tool_results"`
+	ErrorResults    int                         `json:"error_results"`
+
+	// Claude Code splits one API response into several entries that share
+	// the message id and repeat its usage; count each response once
+	seenMessages map[string]bool
+}
+
+// modelCost is the usage and cost of one model, with the price table key it was priced at
+type modelCost struct {
+	Model    string           `json:"model"`
+	PriceKey string           `json:"price_key"`
+	Usage    transcript.Usage `json:"usage"`
+	Cost     float64          `json:"cost_usd"`
+}
+
+// newSessionStats returns empty stats to add entries to
+func newSessionStats() *sessionStats {
+	return &sessionStats{
+		ToolCalls:    make(map[string]int),
+		ToolErrors:   make(map[string]int),
+		ModelUsage:   make(map[string]transcript.Usage),
+		seenMessages: make(map[string]bool),
+	}
+}
+
+// collectSessionStats walks all entries, including abandoned branches,
+// since their tokens were spent all the same
+func collectSessionStats(entries []*transcript.Entry) *sessionStats {
+	stats := newSessionStats()
+	tools := transcript.NewToolIndex(entries)
+	for _, entry := range entries {
+		stats.add(entry, tools)
+	}
+	return stats
+}
+
+// add counts an entry; tools must hold the calls of its tool results
+func (s *sessionStats) add(entry *transcript.Entry, tools transcript.ToolIndex) {
+	if t, ok := entry.Time(); ok {
+		if s.Start.IsZero() || t.Before(s.Start) {
+			s.Start = t
+		}
+		if t.After(s.End) {
+			s.End = t
+		}
+		s.DurationSeconds = s.End.Sub(s.Start).Seconds()
+	}
+
+	msg := entry.Message
+	if msg == nil {
+		return
+	}
+
+	switch entry.Type {
+	case "user":
+		if entry.IsPrompt() {
+			s.UserPrompts++
+			return
+		}
+		for i := range msg.Content {
+			block := &msg.Content[i]
+			if block.Type != transcript.BlockToolResult {
+				continue
+			}
+			s.ToolResults++
+			if block.IsError {
+				s.ErrorResults++
+				s.ToolErrors[toolNameOrUnknown(tools.Name(block.ToolUseID))]++
+			}
+		}
+
+	case "assistant":
+		key := msg.ID
+		if key == "" {
+			key = entry.UUID
+		}
+		first := key == "" || !s.seenMessages[key]
+		s.seenMessages[key] = true
+
+		for _, block := range msg.Content {
+			if block.Type == transcript.BlockToolUse {
+				s.ToolCalls[toolNameOrUnknown(block.Name)]++
+			}
+		}
+
+		if !first {
+			return
+		}
+		s.AssistantTurns++
+		if msg.Usage != nil {
+			s.Usage.Add(*msg.Usage)
+			usage := s.ModelUsage[msg.Model]
+			usage.Add(*msg.Usage)
+			s.ModelUsage[msg.Model] = usage
+		}
+	}
+}
+
+// toolNameOrUnknown substitutes a placeholder for tool uses missing from the transcript
+func toolNameOrUnknown(name string) string {
+	if name == "" {
+		return "unknown"
+	}
+	return name
+}
+
+// calculateStatsCost prices the usage of every model seen in the session
+func calculateStatsCost(stats *sessionStats) {
+	models := make([]string, 0, len(stats.ModelUsage))
+	for model := range stats.ModelUsage {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+
+	stats.Cost = 0
+	stats.Models = make([]modelCost, 0, len(models))
+	for _, model := range models {
+		usage := stats.ModelUsage[model]
+		mc := modelCost{
+			Model:    model,
+			PriceKey: priceKeyForModel(model),
+			Usage:    usage,
+			Cost:     calculateCost(usage, model),
+		}
+		stats.Cost += mc.Cost
+		stats.Models = append(stats.Models, mc)
+	}
+}
+
+// unpricedModels returns the models with usage but no price, which the
+// cost leaves out
+func (s *sessionStats) unpricedModels() []string {
+	var models []string
+	for _, mc := range s.Models {
+		if mc.PriceKey == "" && mc.Usage.Total() > 0 {
+			model := mc.Model
+			if model == "" {
+				model = "unknown"
+			}
+			models = append(models, model)
+		}
+	}
+	return models
+}
+
+// totalToolCalls returns the number of tool uses across all tools
+func (s *sessionStats) totalToolCalls() int {
+	total := 0
+	for _, count := range s.ToolCalls {
+		total += count
+	}
+	return total
+}
+
+// runStatsCommand runs the stats subcommand
+func runStatsCommand(args []string) {
+	statsCmd := flag.NewFlagSet("stats", flag.ExitOnError)
+	statsCmd.StringVar(&cfg.ProjectPath, "p", "", "path to Claude Code project file")
+	statsCmd.BoolVar(&cfg.NoColor, "no-color", false, "disable color output")
+	statsCmd.StringVar(&cfg.PricingFile, "pricing", "", "price table override file (LiteLLM schema)")
+	statsCmd.BoolVar(&cfg.Verbose, "verbose", false, "show cost per model and the price table entry used")
+	jsonFlag := statsCmd.Bool("json", false, "output in JSON format")
+
+	statsCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ccl stats [options] [file]\n\n")
+		fmt.Fprintf(os.Stderr, "Summarize prompts, tool usage, tokens and cost of a session.\n")
+		fmt.Fprintf(os.Stderr, "Defaults to the latest session of the current directory.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		statsCmd.PrintDefaults()
+	}
+
+	if err := statsCmd.Parse(args); err != nil {
+		return
+	}
+	if *jsonFlag {
+		cfg.NoColor = true
+	}
+
+	reader, cleanup, err := getInputReader(statsCmd)
+	if cleanup != nil {
+		defer cleanup()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	entries, err := transcript.ReadAll(reader)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	if err := loadModelPricing(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	stats := collectSessionStats(entries)
+	calculateStatsCost(stats)
+
+	if *jsonFlag {
+		jsonData, _ := json.MarshalIndent(stats, "", "  ")
+		fmt.Fprintln(output, string(jsonData))
+		return
+	}
+	displaySessionStats(stats)
+}
+
+// displaySessionStats prints the session summary as aligned text
+func displaySessionStats(stats *sessionStats) {
+	label := func(name string) string {
+		return fmt.Sprintf("%s%-16s%s", color(colorBold), name, color(colorReset))
+	}
+
+	if !stats.Start.IsZero() {
+		duration := stats.End.Sub(stats.Start).Round(time.Second)
+		fmt.Fprintf(output, "%s%s (%s → %s)\n", label("Duration"), duration,
+			stats.Start.Local().Format("2006-01-02 15:04"), stats.End.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Fprintf(output, "%s%s\n", label("User prompts"), formatNumber(stats.UserPrompts))
+	fmt.Fprintf(output, "%s%s\n", label("Assistant turns"), formatNumber(stats.AssistantTurns))
+
+	fmt.Fprintf(output, "%s%s", label("Tool calls"), formatNumber(stats.totalToolCalls()))
+	if stats.ToolResults > 0 {
+		rate := float64(stats.ErrorResults) / float64(stats.ToolResults) * 100
+		fmt.Fprintf(output, " (%d error%s, %.1f%%)", stats.ErrorResults, pluralize(stats.ErrorResults), rate)
+	}
+	fmt.Fprintln(output)
+	for _, name := range sortedToolNames(stats.ToolCalls) {
+		fmt.Fprintf(output, "  %s%-14s%s %6s", color(colorCyan), name, color(colorReset), formatNumber(stats.ToolCalls[name]))
+		if errors := stats.ToolErrors[name]; errors > 0 {
+			fmt.Fprintf(output, " %s(%d error%s)%s", color(colorRed), errors, pluralize(errors), color(colorReset))
+		}
+		fmt.Fprintln(output)
+	}
+
+	fmt.Fprintf(output, "%sTokens%s\n", color(colorBold), color(colorReset))
+	tokenRows := []struct {
+		name  string
+		count int
+	}{
+		{"Input", stats.Usage.InputTokens},
+		{"Output", stats.Usage.OutputTokens},
+		{"Cache write", stats.Usage.CacheCreationInputTokens},
+		{"Cache read", stats.Usage.CacheReadInputTokens},
+		{"Total", stats.Usage.Total()},
+	}
+	for _, row := range tokenRows {
+		fmt.Fprintf(output, "  %-14s %12s\n", row.name, formatNumber(row.count))
+	}
+
+	fmt.Fprintf(output, "%s$%.4f", label("Cost"), stats.Cost)
+	if unpriced := stats.unpricedModels(); len(unpriced) > 0 {
+		fmt.Fprintf(output, " %s(%d model%s unpriced: %s)%s", color(colorYellow),
+			len(unpriced), pluralize(len(unpriced)), strings.Join(unpriced, ", "), color(colorReset))
+	}
+	fmt.Fprintln(output)
+	if cfg.Verbose {
+		for _, mc := range stats.Models {
+			priceKey := mc.PriceKey
+			if priceKey == "" {
+				priceKey = "unpriced"
+			}
+			fmt.Fprintf(output, "  %s%s%s $%.4f %s@%s%s\n", color(colorCyan), mc.Model, color(colorReset),
+				mc.Cost, color(colorGray), priceKey, color(colorReset))
+		}
+	}
+}
+
+// sortedToolNames orders tools by call count, most used first
+func sortedToolNames(counts map[string]int) []string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// formatNumber formats an integer with thousands separators
+func formatNumber(n int) string {
+	s := strconv.Itoa(n)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	if len(s) <= 3 {
+		return sign + s
+	}
+
+	var b strings.Builder
+	head := len(s) % 3
+	if head > 0 {
+		b.WriteString(s[:head])
+	}
+	for i := head; i < len(s); i += 3 {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(s[i : i+3])
+	}
+	return sign + b.String()
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Sixeight/ccl/pricing"
+	"github.com/Sixeight/ccl/transcript"
+)
+
+func TestCollectSessionStats(t *testing.T) {
+	input := strings.Join([]string{
+		`{"type":"summary","summary":"Fix bug","leafUuid":"a2"}`,
+		`{"type":"user","uuid":"u1","timestamp":"2025-06-22T09:00:00Z","message":{"role":"user","content":"Fix the bug"}}`,
+		// Written by Claude Code rather than typed, so not prompts
+		`{"type":"user","uuid":"m1","isMeta":true,"message":{"role":"user","content":"Caveat: The messages below were generated by the user while running local commands."}}`,
+		`{"type":"user","uuid":"m2","message":{"role":"user","content":"<command-name>/model</command-name>\n<command-message>model</command-message>"}}`,
+		`{"type":"user","uuid":"m3","message":{"role":"user","content":[{"type":"text","text":"[Request interrupted by user]"}]}}`,
+		// One API response split into two entries sharing the message id
+		`{"type":"assistant","uuid":"a1","timestamp":"2025-06-22T09:00:05Z","message":{"id":"msg_1","model":"claude-sonnet-4","content":[{"type":"text","text":"Looking"}],"usage":{"input_tokens":10,"output_tokens":5,"cache_read_input_tokens":100}}}`,
+		`{"type":"assistant","uuid":"a2","timestamp":"2025-06-22T09:00:06Z","message":{"id":"msg_1","model":"claude-sonnet-4","content":[{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"make"}},{"type":"tool_use","id":"t2","name":"Read","input":{}}],"usage":{"input_tokens":10,"output_tokens":5,"cache_read_input_tokens":100}}}`,
+		`{"type":"user","uuid":"u2","timestamp":"2025-06-22T09:00:10Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"exit 2","is_error":true},{"type":"tool_result","tool_use_id":"t2","content":"ok"}]}}`,
+		`{"type":"assistant","uuid":"a3","timestamp":"2025-06-22T09:01:30Z","message":{"id":"msg_2","model":"claude-sonnet-4","content":[{"type":"tool_use","id":"t3","name":"Bash","input":{"command":"make"}}],"usage":{"input_tokens":20,"output_tokens":7,"cache_creation_input_tokens":3}}}`,
+	}, "\n")
+	entries, err := transcript.ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	stats := collectSessionStats(entries)
+
+	if stats.UserPrompts != 1 {
+		t.Errorf("UserPrompts = %d; want 1", stats.UserPrompts)
+	}
+	if stats.AssistantTurns != 2 {
+		t.Errorf("AssistantTurns = %d; want 2", stats.AssistantTurns)
+	}
+	if stats.ToolCalls["Bash"] != 2 || stats.ToolCalls["Read"] != 1 {
+		t.Errorf("ToolCalls = %v; want Bash:2 Read:1", stats.ToolCalls)
+	}
+	if stats.ToolResults != 2 || stats.ErrorResults != 1 || stats.ToolErrors["Bash"] != 1 {
+		t.Errorf("results = %d, errors = %d (%v); want 2, 1 (Bash:1)", stats.ToolResults, stats.ErrorResults, stats.ToolErrors)
+	}
+
+	expectedUsage := transcript.Usage{InputTokens: 30, OutputTokens: 12, CacheCreationInputTokens: 3, CacheReadInputTokens: 100}
+	if stats.Usage != expectedUsage {
+		t.Errorf("Usage = %+v; want %+v", stats.Usage, expectedUsage)
+	}
+	if stats.DurationSeconds != 90 {
+		t.Errorf("DurationSeconds = %v; want 90", stats.DurationSeconds)
+	}
+}
+
+func TestDisplaySessionStatsUnpriced(t *testing.T) {
+	origCfg, origOutput, origPrices := cfg, output, modelPrices
+	defer func() { cfg, output, modelPrices = origCfg, origOutput, origPrices }()
+	cfg = Config{NoColor: true}
+	modelPrices = pricing.NewResolver(pricing.Table{
+		"claude-sonnet-4-20250514": {InputCostPerToken: 0.000003},
+	})
+
+	input := strings.Join([]string{
+		`{"type":"assistant","uuid":"a1","message":{"id":"msg_1","model":"claude-sonnet-4-20250514","content":[],"usage":{"input_tokens":1000}}}`,
+		`{"type":"assistant","uuid":"a2","message":{"id":"msg_2","model":"claude-opus-4-6","content":[],"usage":{"input_tokens":1000}}}`,
+		`{"type":"assistant","uuid":"a3","message":{"id":"msg_3","model":"<synthetic>","content":[],"usage":{"input_tokens":0}}}`,
+	}, "\n")
+	entries, err := transcript.ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	stats := collectSessionStats(entries)
+	calculateStatsCost(stats)
+
+	var buf bytes.Buffer
+	output = &buf
+	displaySessionStats(stats)
+	if want := "$0.0030 (1 model unpriced: claude-opus-4-6)\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("output = %q; want %q", buf.String(), want)
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		input    int
+		expected string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1,000"},
+		{123456, "123,456"},
+		{1234567, "1,234,567"},
+		{-9876543, "-9,876,543"},
+	}
+
+	for _, tt := range tests {
+		if got := formatNumber(tt.input); got != tt.expected {
+			t.Errorf("formatNumber(%d) = %q; want %q", tt.input, got, tt.expected)
+		}
+	}
+}
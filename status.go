@@ -10,6 +10,8 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/Sixeight/ccl/projects"
 )
 
 // ClaudeConfig represents the structure of .claude.json
@@ -51,7 +53,7 @@ type LocalSettings struct {
 
 // loadClaudeConfig loads the global .claude.json configuration
 func loadClaudeConfig() (*ClaudeConfig, error) {
-	configDir := getClaudeConfigDir()
+	configDir := projects.ConfigDir()
 	configPath := filepath.Join(configDir, ".claude.json")
 
 	configData, err := os.ReadFile(configPath)
@@ -448,9 +450,7 @@ func showAllProjectsInfo(config ClaudeConfig) {
 
 // findProjectFileForPath finds the project file for a given path
 func findProjectFileForPath(projectPath string) string {
-	encoded := encodeDirectoryPath(projectPath)
-	configDir := getClaudeConfigDir()
-	projectDir := filepath.Join(configDir, "projects", encoded)
+	projectDir := projects.Dir(projectPath)
 
 	files, err := os.ReadDir(projectDir)
 	if err != nil {
@@ -487,7 +487,7 @@ type HistorySearchResult struct {
 // searchHistory searches message history for matching patterns
 func searchHistory(query string) {
 	// Load global .claude.json
-	configDir := getClaudeConfigDir()
+	configDir := projects.ConfigDir()
 	configPath := filepath.Join(configDir, ".claude.json")
 
 	configData, err := os.ReadFile(configPath)
@@ -7,6 +7,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/Sixeight/ccl/projects"
 )
 
 func TestFormatDuration(t *testing.T) {
@@ -173,7 +175,7 @@ func TestTruncateUTF8(t *testing.T) {
 func TestEncodeDirectoryPath(t *testing.T) {
 	// Test that the function exists and returns a string
 	path := "/Users/test/project"
-	encoded := encodeDirectoryPath(path)
+	encoded := projects.EncodePath(path)
 	if encoded == "" {
 		t.Error("encodeDirectoryPath returned empty string")
 	}
@@ -232,7 +234,7 @@ func TestFindProjectFileForPath(t *testing.T) {
 
 	// Create project directory structure
 	projectPath := "/test/project"
-	encoded := encodeDirectoryPath(projectPath)
+	encoded := projects.EncodePath(projectPath)
 	projectDir := filepath.Join(tempDir, "projects", encoded)
 
 	if err := os.MkdirAll(projectDir, 0o755); err != nil {
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Sixeight/ccl/transcript"
+	"golang.org/x/term"
+)
+
+// How often the status line is printed when the output is not a terminal
+const statusInterval = 30 * time.Second
+
+// sessionActivity tracks what a followed session is doing at the moment
+type sessionActivity struct {
+	todo    string        // The TodoWrite item in progress
+	running []runningTool // Tool calls without a result yet, oldest first
+}
+
+// runningTool is a tool call waiting for its result
+type runningTool struct {
+	id    string
+	name  string
+	start time.Time
+}
+
+// add updates the activity with an entry
+func (a *sessionActivity) add(entry *transcript.Entry) {
+	msg := entry.Message
+	if msg == nil {
+		return
+	}
+
+	switch entry.Type {
+	case "assistant":
+		start, _ := entry.Time()
+		for i := range msg.Content {
+			block := &msg.Content[i]
+			if block.Type != transcript.BlockToolUse {
+				continue
+			}
+			a.running = append(a.running, runningTool{id: block.ID, name: block.Name, start: start})
+			if block.Name == "TodoWrite" {
+				a.todo = ""
+				for _, todo := range block.Todos() {
+					if todo.Status == "in_progress" {
+						a.todo = todo.Content
+						break
+					}
+				}
+			}
+		}
+
+	case "user":
+		if !msg.Content.HasType(transcript.BlockToolResult) {
+			// A new prompt: calls still without a result were interrupted
+			a.running = nil
+			return
+		}
+		for _, block := range msg.Content {
+			if block.Type != transcript.BlockToolResult {
+				continue
+			}
+			for i, tool := range a.running {
+				if tool.id == block.ToolUseID {
+					a.running = append(a.running[:i], a.running[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+}
+
+// statusText returns the live state of a session on one line
+func statusText(stats *sessionStats, activity *sessionActivity, now time.Time) string {
+	var parts []string
+	if !stats.Start.IsZero() {
+		parts = append(parts, now.Sub(stats.Start).Round(time.Second).String())
+	}
+	parts = append(parts, fmt.Sprintf("%s turn%s", formatNumber(stats.AssistantTurns), pluralize(stats.AssistantTurns)))
+
+	usage := stats.Usage
+	tokens := fmt.Sprintf("↑%s ↓%s", formatNumber(usage.InputTokens), formatNumber(usage.OutputTokens))
+	if usage.CacheReadInputTokens > 0 {
+		tokens += " *" + formatNumber(usage.CacheReadInputTokens)
+	}
+	if usage.CacheCreationInputTokens > 0 {
+		tokens += " +" + formatNumber(usage.CacheCreationInputTokens)
+	}
+	parts = append(parts, tokens)
+
+	if modelPrices != nil {
+		calculateStatsCost(stats)
+		parts = append(parts, fmt.Sprintf("$%.4f", stats.Cost))
+	}
+	if activity.todo != "" {
+		icon, _ := getTodoStatusIcon("in_progress")
+		parts = append(parts, icon+" "+truncateRunes(activity.todo, 50))
+	}
+	if n := len(activity.running); n > 0 {
+		tool := activity.running[n-1]
+		running := "running " + tool.name
+		if !tool.start.IsZero() {
+			running += " " + now.Sub(tool.start).Round(time.Second).String()
+		}
+		if n > 1 {
+			running += fmt.Sprintf(" (+%d)", n-1)
+		}
+		parts = append(parts, running)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// statusLine shows the live state of a followed session: as a footer kept
+// below the output on a terminal, or as a line printed every statusInterval
+// (when there is something new) otherwise. Output goes through it, and is
+// held on a terminal until the next update redraws the footer below it.
+type statusLine struct {
+	w       io.Writer
+	fd      int // Terminal the footer is drawn on, or -1
+	held    bytes.Buffer
+	footer  bool      // The footer is on screen
+	printed time.Time // When the last line was printed, without a terminal
+	changed bool      // Entries were shown since then
+}
+
+// newStatusLine returns a status line writing to w, as a footer
+// when w is a terminal
+func newStatusLine(w io.Writer) *statusLine {
+	s := &statusLine{w: w, fd: -1, printed: time.Now()}
+	if file, ok := w.(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+		s.fd = int(file.Fd())
+	}
+	return s
+}
+
+func (s *statusLine) Write(b []byte) (int, error) {
+	s.changed = true
+	if s.fd < 0 {
+		return s.w.Write(b)
+	}
+	return s.held.Write(b)
+}
+
+// update shows the output held so far and the status below it
+func (s *statusLine) update(text string) {
+	if s.fd < 0 {
+		if s.changed && time.Since(s.printed) >= statusInterval {
+			fmt.Fprintf(s.w, "%s[status] %s%s\n", color(colorGray), text, color(colorReset))
+			s.printed, s.changed = time.Now(), false
+		}
+		return
+	}
+
+	// Keep the footer on one line, so that it can be redrawn in place
+	if width, _, err := term.GetSize(s.fd); err == nil && width > 1 {
+		text = truncateDisplay(text, width-1)
+	}
+	var frame bytes.Buffer
+	if s.footer {
+		frame.WriteString("\r" + escClearLine)
+	}
+	frame.Write(s.held.Bytes())
+	frame.WriteString(color(escReverse) + text + color(colorReset))
+	_, _ = s.w.Write(frame.Bytes())
+	s.held.Reset()
+	s.footer = true
+}
+
+// close removes the footer, showing the output still held
+func (s *statusLine) close() {
+	if s.fd < 0 {
+		return
+	}
+	if s.footer {
+		_, _ = io.WriteString(s.w, "\r"+escClearLine)
+	}
+	_, _ = s.w.Write(s.held.Bytes())
+	s.held.Reset()
+	s.footer = false
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+func TestStatusText(t *testing.T) {
+	origCfg, origPrices := cfg, modelPrices
+	defer func() {
+		cfg, modelPrices = origCfg, origPrices
+	}()
+	cfg = Config{NoColor: true}
+	modelPrices = nil
+
+	lines := []string{
+		`{"type":"user","uuid":"u1","timestamp":"2025-06-22T09:00:00Z","message":{"role":"user","content":"fix it"}}`,
+		`{"type":"assistant","uuid":"a1","timestamp":"2025-06-22T09:00:05Z","message":{"id":"m1","content":[{"type":"tool_use","id":"t1","name":"TodoWrite","input":{"todos":[{"content":"Read the code","status":"completed"},{"content":"Fix the bug","status":"in_progress"}]}}],"usage":{"input_tokens":100,"output_tokens":20,"cache_read_input_tokens":1000}}}`,
+		`{"type":"user","uuid":"u2","timestamp":"2025-06-22T09:00:06Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"}]}}`,
+		`{"type":"assistant","uuid":"a2","timestamp":"2025-06-22T09:00:10Z","message":{"id":"m2","content":[{"type":"tool_use","id":"t2","name":"Bash","input":{"command":"make"}},{"type":"tool_use","id":"t3","name":"Bash","input":{"command":"make test"}}],"usage":{"input_tokens":10,"output_tokens":30}}}`,
+	}
+	reader := transcript.NewReader(strings.NewReader(strings.Join(lines, "\n")))
+	stats, activity := newSessionStats(), &sessionActivity{}
+	tools := make(transcript.ToolIndex)
+	for reader.Next() {
+		entry := reader.Entry()
+		tools.Collect(entry)
+		stats.add(entry, tools)
+		activity.add(entry)
+	}
+
+	now := time.Date(2025, 6, 22, 9, 1, 0, 0, time.UTC)
+	want := "1m0s · 2 turns · ↑110 ↓50 *1,000 · → Fix the bug · running Bash 50s (+1)"
+	if got := statusText(stats, activity, now); got != want {
+		t.Errorf("statusText() = %q; want %q", got, want)
+	}
+
+	// A new prompt means the calls without a result were interrupted
+	activity.add(&transcript.Entry{Type: "user", Message: &transcript.Message{Role: "user", Content: transcript.Content{{Type: "text", Text: "stop"}}}})
+	if len(activity.running) != 0 {
+		t.Errorf("running = %v; want none after a new prompt", activity.running)
+	}
+}
+
+func TestStatusLineWithoutTerminal(t *testing.T) {
+	origCfg := cfg
+	defer func() { cfg = origCfg }()
+	cfg = Config{NoColor: true}
+
+	var buf bytes.Buffer
+	s := newStatusLine(&buf)
+	s.update("quiet")
+	if buf.Len() != 0 {
+		t.Errorf("output = %q; want nothing before anything is shown", buf.String())
+	}
+
+	_, _ = s.Write([]byte("entry\n"))
+	s.update("too soon")
+	s.printed = time.Now().Add(-statusInterval)
+	s.update("1 turn")
+	s.update("again")
+	s.close()
+	if got, want := buf.String(), "entry\n[status] 1 turn\n"; got != want {
+		t.Errorf("output = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+// followStream displays the entries of a stream as they arrive until it
+// ends or Ctrl-C, then prints the running totals. When next is not nil,
+// it is called at the end of a stream for the one after it, as for a
+// named pipe that is written to again.
+func followStream(r io.Reader, next func() (io.Reader, error)) error {
+	renderer := newLiveRenderer()
+	renderer.showStatus()
+	defer renderer.stopStatus()
+
+	// Stop cleanly on Ctrl-C
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	// Reading blocks, so it is done aside to stay responsive to Ctrl-C
+	entries := make(chan *transcript.Entry, 64)
+	done := make(chan error, 1)
+	go func() {
+		for {
+			reader := transcript.NewReader(r)
+			for reader.Next() {
+				entries <- reader.Entry()
+			}
+			err := reader.Err()
+			if err == nil && next != nil {
+				if r, err = next(); err == nil {
+					continue
+				}
+			}
+			done <- err
+			return
+		}
+	}()
+
+	// The status line shows the time passing while nothing arrives
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case entry := <-entries:
+			renderer.render(entry)
+			if len(entries) == 0 {
+				renderer.updateStatus()
+			}
+		case <-ticker.C:
+			renderer.updateStatus()
+		case err := <-done:
+			// The reader queued its last entries before it was done
+			for len(entries) > 0 {
+				renderer.render(<-entries)
+			}
+			renderer.displayTotals()
+			return err
+		case <-stop:
+			renderer.displayTotals()
+			return nil
+		}
+	}
+}
+
+// isNamedPipe reports whether a file is a FIFO
+func isNamedPipe(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeNamedPipe != 0
+}
+
+// reopenPipe returns a function waiting for the next writer of a named pipe
+func reopenPipe(file *os.File) func() (io.Reader, error) {
+	current := file
+	return func() (io.Reader, error) {
+		if current != file {
+			_ = current.Close()
+		}
+		next, err := os.Open(file.Name())
+		if err != nil {
+			return nil, fmt.Errorf("reopening pipe: %w", err)
+		}
+		current = next
+		return next, nil
+	}
+}
+
+// processExec follows the output of a command, such as 'ssh host tail -f ...'
+func processExec(command string) error {
+	if err := checkLiveOutput("--exec"); err != nil {
+		return err
+	}
+	if logConfig.rng.last > 0 {
+		return fmt.Errorf("--last is not supported with --exec")
+	}
+
+	cmd := shellCommand(command)
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("running --exec command: %w", err)
+	}
+
+	streamErr := followStream(stdout, nil)
+	stopCommand(cmd) // Still running after Ctrl-C
+	waitErr := cmd.Wait()
+	if streamErr != nil {
+		return streamErr
+	}
+
+	// A command ended by a signal was stopped along with ccl
+	var exitErr *exec.ExitError
+	if errors.As(waitErr, &exitErr) && exitErr.Exited() {
+		return fmt.Errorf("--exec command failed: %w", waitErr)
+	}
+	return nil
+}
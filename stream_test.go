@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestFollowStream(t *testing.T) {
+	origCfg, origLogConfig, origOutput := cfg, logConfig, output
+	defer func() {
+		cfg, logConfig, output = origCfg, origLogConfig, origOutput
+	}()
+	cfg = Config{NoColor: true, OutputFormat: "text", Compact: true}
+	logConfig = LogConfig{}
+
+	first := strings.Join([]string{
+		`{"type":"user","uuid":"u1","timestamp":"2025-06-22T09:00:00Z","message":{"role":"user","content":"run it"}}`,
+		`{"type":"assistant","uuid":"a1","timestamp":"2025-06-22T09:00:05Z","message":{"id":"m1","model":"claude-sonnet-4-20250514","content":[{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"make"}}],"usage":{"input_tokens":100,"output_tokens":20}}}`,
+	}, "\n")
+	// The result arrives from the next writer of the pipe
+	second := `{"type":"user","uuid":"u2","timestamp":"2025-06-22T09:01:05Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"failed","is_error":true}]}}`
+
+	var buf bytes.Buffer
+	output = &buf
+	streams := []string{second}
+	next := func() (io.Reader, error) {
+		if len(streams) == 0 {
+			return nil, io.ErrClosedPipe
+		}
+		r := strings.NewReader(streams[0])
+		streams = streams[1:]
+		return r, nil
+	}
+	if err := followStream(strings.NewReader(first), next); err != io.ErrClosedPipe {
+		t.Fatalf("followStream() error = %v; want the error of next", err)
+	}
+
+	got := buf.String()
+	for _, expected := range []string{
+		"run it",
+		"[Tool: Bash] make",
+		"[ERROR]",
+		"\n── 1 prompt · 1 turn · 1 tool call (1 error) · 120 tokens · 1m5s ──\n",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("output = %q; want %q", got, expected)
+		}
+	}
+}
+
+func TestFollowStreamRendersAllEntries(t *testing.T) {
+	origCfg, origLogConfig, origOutput := cfg, logConfig, output
+	defer func() {
+		cfg, logConfig, output = origCfg, origLogConfig, origOutput
+	}()
+	cfg = Config{NoColor: true, OutputFormat: "text", Compact: true}
+	logConfig = LogConfig{}
+
+	// More entries than the channel between the reader and the renderer holds
+	var lines []string
+	for i := range 100 {
+		lines = append(lines,
+			fmt.Sprintf(`{"type":"user","uuid":"u%d","timestamp":"2025-06-22T09:00:00Z","message":{"role":"user","content":"prompt %d"}}`, i, i),
+			fmt.Sprintf(`{"type":"assistant","uuid":"a%d","timestamp":"2025-06-22T09:00:01Z","message":{"id":"m%d","content":"answer %d","usage":{"input_tokens":1,"output_tokens":1}}}`, i, i, i),
+		)
+	}
+
+	var buf bytes.Buffer
+	output = &buf
+	if err := followStream(strings.NewReader(strings.Join(lines, "\n")), nil); err != nil {
+		t.Fatalf("followStream() error = %v", err)
+	}
+	if got, want := buf.String(), "\n── 100 prompts · 100 turns · 0 tool calls · 200 tokens · 1.0s ──\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("output ends with %q; want %q", got[max(0, len(got)-80):], want)
+	}
+}
+
+func TestProcessExecRejectsLast(t *testing.T) {
+	origCfg, origLogConfig := cfg, logConfig
+	defer func() {
+		cfg, logConfig = origCfg, origLogConfig
+	}()
+	cfg = Config{NoColor: true, OutputFormat: "text"}
+	logConfig = LogConfig{}
+	logConfig.rng.last = 5
+
+	err := processExec("echo never run")
+	if err == nil || !strings.Contains(err.Error(), "--last is not supported") {
+		t.Errorf("processExec() error = %v; want --last is not supported", err)
+	}
+}
+
+func TestProcessStreamingTotals(t *testing.T) {
+	origCfg, origLogConfig, origOutput := cfg, logConfig, output
+	defer func() {
+		cfg, logConfig, output = origCfg, origLogConfig, origOutput
+	}()
+	cfg = Config{NoColor: true, OutputFormat: "text", Compact: true}
+
+	input := strings.Join([]string{
+		`{"type":"user","uuid":"u1","timestamp":"2025-06-22T09:00:00Z","message":{"role":"user","content":"first"}}`,
+		`{"type":"assistant","uuid":"a1","timestamp":"2025-06-22T09:00:02Z","message":{"id":"m1","content":"one","usage":{"input_tokens":10,"output_tokens":5}}}`,
+		`{"type":"user","uuid":"u2","timestamp":"2025-06-22T09:00:03Z","message":{"role":"user","content":"second"}}`,
+		`{"type":"assistant","uuid":"a2","timestamp":"2025-06-22T09:00:04Z","message":{"id":"m2","content":"two","usage":{"input_tokens":10,"output_tokens":5}}}`,
+	}, "\n")
+
+	tests := []struct {
+		name    string
+		last    int
+		missing string
+	}{
+		{"all entries", 0, ""},
+		{"last turn counts the whole input", 1, "first"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logConfig = LogConfig{}
+			logConfig.rng.last = tt.last
+			var buf bytes.Buffer
+			output = &buf
+
+			if err := processStreaming(strings.NewReader(input)); err != nil {
+				t.Fatalf("processStreaming() error = %v", err)
+			}
+			got := buf.String()
+			if want := "\n── 2 prompts · 2 turns · 0 tool calls · 30 tokens · 4.0s ──\n"; !strings.HasSuffix(got, want) {
+				t.Errorf("output = %q; want it to end with %q", got, want)
+			}
+			if tt.missing != "" && strings.Contains(got, tt.missing) {
+				t.Errorf("output = %q; want no %q", got, tt.missing)
+			}
+		})
+	}
+}
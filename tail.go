@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"time"
+)
+
+// How often a followed file is checked without file events
+const pollInterval = 100 * time.Millisecond
+
+// How often a followed file is checked even with file events,
+// in case one was missed (e.g. on network file systems)
+const watchInterval = 2 * time.Second
+
+// Size of each read from a followed file
+const tailChunkSize = 64 * 1024
+
+// tailer reads the lines appended to a file as it grows. A line is only
+// returned once its newline arrives. When the file is truncated it is read
+// again from the start, and when it is replaced (rotated) the new file is read.
+type tailer struct {
+	path    string
+	file    *os.File
+	offset  int64  // Position up to which the file was read
+	partial []byte // Bytes after the last newline read
+	buf     []byte
+	watcher *fileWatcher // nil when polling
+	opened  bool         // file was opened by the tailer after a rotation
+}
+
+// newTailer follows a file from offset; wait polls it unless watch is called
+func newTailer(file *os.File, offset int64) *tailer {
+	return &tailer{path: file.Name(), file: file, offset: offset, buf: make([]byte, tailChunkSize)}
+}
+
+// watch makes wait return on file events, when available
+func (t *tailer) watch() {
+	watcher, err := newFileWatcher()
+	if err != nil {
+		return
+	}
+	if err := watcher.watchFile(t.path); err != nil {
+		_ = watcher.Close()
+		return
+	}
+	t.watcher = watcher
+}
+
+// lines returns the complete lines appended since the last call
+func (t *tailer) lines() ([][]byte, error) {
+	lines, err := t.readLines()
+	if err != nil {
+		return nil, err
+	}
+
+	// A new file at the path replaces the one being read; what was left
+	// of the old file has been read above
+	if t.rotated() {
+		file, err := os.Open(t.path)
+		if err != nil {
+			return lines, nil // Try again on the next call
+		}
+		if t.opened {
+			_ = t.file.Close()
+		}
+		t.file, t.offset, t.partial, t.opened = file, 0, nil, true
+		if t.watcher != nil {
+			_ = t.watcher.rewatch(t.path)
+		}
+		more, err := t.readLines()
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, more...)
+	}
+	return lines, nil
+}
+
+// readLines reads the current file from the offset to its end
+func (t *tailer) readLines() ([][]byte, error) {
+	info, err := t.file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < t.offset {
+		// Truncated: start over
+		t.offset, t.partial = 0, nil
+	}
+
+	var lines [][]byte
+	for {
+		n, err := t.file.ReadAt(t.buf, t.offset)
+		t.offset += int64(n)
+		data := t.buf[:n]
+		for {
+			i := bytes.IndexByte(data, '\n')
+			if i < 0 {
+				break
+			}
+			line := append(append([]byte(nil), t.partial...), data[:i]...)
+			t.partial = nil
+			if len(bytes.TrimSpace(line)) > 0 {
+				lines = append(lines, line)
+			}
+			data = data[i+1:]
+		}
+		t.partial = append(t.partial, data...)
+
+		if err == io.EOF || n == 0 {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// rotated reports whether the path now names a different file
+func (t *tailer) rotated() bool {
+	current, err := t.file.Stat()
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(t.path)
+	return err == nil && !os.SameFile(current, info)
+}
+
+// wait blocks until the file may have changed; it returns false once
+// stop receives a value
+func (t *tailer) wait(stop <-chan os.Signal) bool {
+	return waitForChange(&t.watcher, stop)
+}
+
+// waitForChange blocks until the watcher reports a change or, without one,
+// until the next poll; it returns false once stop receives a value.
+// A watcher that failed is closed and cleared, falling back to polling.
+func waitForChange(watcher **fileWatcher, stop <-chan os.Signal) bool {
+	var events <-chan struct{}
+	interval := pollInterval
+	if *watcher != nil {
+		events, interval = (*watcher).events, watchInterval
+	}
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case <-stop:
+		return false
+	case _, ok := <-events:
+		if !ok {
+			_ = (*watcher).Close()
+			*watcher = nil
+		}
+	case <-timer.C:
+	}
+	return true
+}
+
+// Close stops watching and closes any file opened after a rotation;
+// the first file is left to its opener
+func (t *tailer) Close() {
+	if t.watcher != nil {
+		_ = t.watcher.Close()
+	}
+	if t.opened {
+		_ = t.file.Close()
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTailer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(path, []byte("one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = file.Close() }()
+
+	tail := newTailer(file, int64(len("one\n")))
+	defer tail.Close()
+
+	appendTo := func(s string) {
+		t.Helper()
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.WriteString(s); err != nil {
+			t.Fatal(err)
+		}
+		_ = f.Close()
+	}
+	expectLines := func(step, expected string) {
+		t.Helper()
+		lines, err := tail.lines()
+		if err != nil {
+			t.Fatalf("%s: lines() error = %v", step, err)
+		}
+		var got []string
+		for _, line := range lines {
+			got = append(got, string(line))
+		}
+		if strings.Join(got, "|") != expected {
+			t.Errorf("%s: lines() = %q; want %q", step, strings.Join(got, "|"), expected)
+		}
+	}
+
+	expectLines("nothing new", "")
+
+	// A line written in two parts is returned once complete
+	appendTo(`{"half":`)
+	expectLines("partial line", "")
+	appendTo("1}\ntwo\nthr")
+	expectLines("completed line", `{"half":1}|two`)
+
+	// A truncated file is read from the start
+	if err := os.WriteFile(path, []byte("new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	expectLines("truncated", "new")
+
+	// A file moved into place replaces the followed one
+	rotated := path + ".tmp"
+	if err := os.WriteFile(rotated, []byte("rotated\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(rotated, path); err != nil {
+		t.Fatal(err)
+	}
+	expectLines("rotated", "rotated")
+	appendTo("after\n")
+	expectLines("after rotation", "after")
+}
+
+func TestTailerWait(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = file.Close() }()
+
+	tail := newTailer(file, 0)
+	tail.watch()
+	defer tail.Close()
+
+	stop := make(chan os.Signal, 1)
+	stop <- os.Interrupt
+	if tail.wait(stop) {
+		t.Errorf("wait() = true after stop; want false")
+	}
+
+	// A write wakes the waiter well before the fallback interval
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = os.WriteFile(path, []byte("x\n"), 0o644)
+	}()
+	start := time.Now()
+	if !tail.wait(make(chan os.Signal)) {
+		t.Fatalf("wait() = false; want true")
+	}
+	if elapsed := time.Since(start); elapsed >= watchInterval {
+		t.Errorf("wait() took %v; want a wakeup on write", elapsed)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// notifyResize is a no-op on this platform
+func notifyResize(ch chan<- os.Signal) {}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+// Lines of a thinking block shown unless --thinking is given
+const maxThinkingLines = 5
+
+// isThinkingBlock reports whether a block holds extended thinking
+func isThinkingBlock(block *transcript.ContentBlock) bool {
+	return block.Type == transcript.BlockThinking || block.Type == transcript.BlockRedactedThinking
+}
+
+// hasThinking reports whether a message has a thinking block
+func hasThinking(message *transcript.Message) bool {
+	return message != nil && (message.Content.HasType(transcript.BlockThinking) ||
+		message.Content.HasType(transcript.BlockRedactedThinking))
+}
+
+// isOnlyThinking reports whether all content of a message is thinking
+func isOnlyThinking(message *transcript.Message) bool {
+	if message == nil || len(message.Content) == 0 {
+		return false
+	}
+	for i := range message.Content {
+		if !isThinkingBlock(&message.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// thinkingOnly reports whether --role asks for the thinking of assistant
+// messages without the rest of them
+func thinkingOnly() bool {
+	roles := parseCommaSeparated(cfg.Role)
+	thinking, assistant := false, false
+	for _, role := range roles {
+		thinking = thinking || role == "thinking"
+		assistant = assistant || role == "assistant"
+	}
+	return thinking && !assistant
+}
+
+// displayThinking prints a thinking block dimmed, truncated unless --thinking
+func displayThinking(w io.Writer, block *transcript.ContentBlock, indent string) {
+	if block.Type == transcript.BlockRedactedThinking {
+		fmt.Fprintf(w, "%s%s[Thinking] (redacted)%s\n", indent, color(colorGray), color(colorReset))
+		return
+	}
+
+	fmt.Fprintf(w, "%s%s[Thinking]%s\n", indent, color(colorGray), color(colorReset))
+	lines := strings.Split(strings.TrimRight(block.Thinking, "\n"), "\n")
+	shown := lines
+	// +2 as for other truncated text: don't hide just a line or two
+	if !logConfig.thinking && len(lines) > maxThinkingLines+2 {
+		shown = lines[:maxThinkingLines]
+	}
+	for _, line := range shown {
+		fmt.Fprintf(w, "%s  %s%s%s\n", indent, color(colorGray), line, color(colorReset))
+	}
+	if remaining := len(lines) - len(shown); remaining > 0 {
+		fmt.Fprintf(w, "%s  %s... (%d more lines, use --thinking to show)%s\n",
+			indent, color(colorGray), remaining, color(colorReset))
+	}
+}
+
+// thinkingSummary returns the compact summary of a thinking block
+func thinkingSummary(block *transcript.ContentBlock) string {
+	if block.Type == transcript.BlockRedactedThinking {
+		return "[Thinking: redacted]"
+	}
+	firstLine := strings.TrimSpace(strings.SplitN(strings.TrimSpace(block.Thinking), "\n", 2)[0])
+	return fmt.Sprintf("[Thinking: %s]", truncateRunes(firstLine, 40))
+}
+
+// displayThinkingAsMarkdown renders a thinking block as a collapsed quote
+func displayThinkingAsMarkdown(block *transcript.ContentBlock) {
+	if block.Type == transcript.BlockRedactedThinking {
+		fmt.Fprintf(output, "<details>\n<summary>Thinking (redacted)</summary>\n\n</details>\n\n")
+		return
+	}
+	text := strings.TrimSpace(block.Thinking)
+	if text == "" {
+		return
+	}
+	fmt.Fprintf(output, "<details>\n<summary>Thinking</summary>\n\n%s\n\n</details>\n\n", markdownQuote(text))
+}
+
+// displayThinkingAsHTML renders a thinking block as a collapsed, dimmed section
+func displayThinkingAsHTML(block *transcript.ContentBlock) {
+	if block.Type == transcript.BlockRedactedThinking {
+		fmt.Fprintf(output, "<details class=\"thinking\">\n<summary>thinking (redacted)</summary>\n</details>\n")
+		return
+	}
+	fmt.Fprintf(output, "<details class=\"thinking\">\n<summary>thinking</summary>\n<pre>%s</pre>\n</details>\n",
+		html.EscapeString(block.Thinking))
+}
+
+// withoutThinking returns a JSON entry with its thinking blocks removed
+func withoutThinking(raw json.RawMessage) (json.RawMessage, bool) {
+	var entry map[string]interface{}
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return raw, false
+	}
+	message, ok := entry["message"].(map[string]interface{})
+	if !ok {
+		return raw, false
+	}
+	content, ok := message["content"].([]interface{})
+	if !ok {
+		return raw, false
+	}
+
+	kept := make([]interface{}, 0, len(content))
+	for _, item := range content {
+		if block, ok := item.(map[string]interface{}); ok &&
+			(block["type"] == transcript.BlockThinking || block["type"] == transcript.BlockRedactedThinking) {
+			continue
+		}
+		kept = append(kept, item)
+	}
+	if len(kept) == len(content) {
+		return raw, false
+	}
+	message["content"] = kept
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return raw, false
+	}
+	return data, true
+}
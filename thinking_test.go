@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+func TestThinkingFilters(t *testing.T) {
+	origCfg, origLogConfig := cfg, logConfig
+	defer func() {
+		cfg, logConfig = origCfg, origLogConfig
+	}()
+
+	input := strings.Join([]string{
+		`{"type":"user","uuid":"u1","message":{"role":"user","content":"why?"}}`,
+		`{"type":"assistant","uuid":"a1","message":{"content":[{"type":"thinking","thinking":"hmm","signature":"s"}]}}`,
+		`{"type":"assistant","uuid":"a2","message":{"content":[{"type":"redacted_thinking","data":"x"},{"type":"text","text":"Because."}]}}`,
+		`{"type":"assistant","uuid":"a3","message":{"content":[{"type":"text","text":"Done."}]}}`,
+	}, "\n")
+	entries, err := transcript.ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	tools := transcript.NewToolIndex(entries)
+
+	tests := map[string]struct {
+		role       string
+		noThinking bool
+		expected   string
+	}{
+		"default":              {"", false, "u1 a1 a2 a3"},
+		"no thinking":          {"", true, "u1 a2 a3"},
+		"thinking role":        {"thinking", false, "a1 a2"},
+		"thinking and user":    {"user,thinking", false, "u1 a1 a2"},
+		"thinking role hidden": {"thinking", true, ""},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg = Config{Role: tt.role}
+			logConfig = LogConfig{noThinking: tt.noThinking}
+			var shown []string
+			for _, entry := range entries {
+				if shouldDisplayEntryWithToolInfo(entry.Type, entry, tools) {
+					shown = append(shown, entry.UUID)
+				}
+			}
+			if got := strings.Join(shown, " "); got != tt.expected {
+				t.Errorf("shown = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDisplayThinking(t *testing.T) {
+	origCfg, origLogConfig, origOutput := cfg, logConfig, output
+	defer func() {
+		cfg, logConfig, output = origCfg, origLogConfig, origOutput
+	}()
+	cfg = Config{NoColor: true}
+
+	block := &transcript.ContentBlock{Type: transcript.BlockThinking, Thinking: "1\n2\n3\n4\n5\n6\n7\n8\n"}
+
+	var buf bytes.Buffer
+	output = &buf
+	displayThinking(output, block, "  ")
+	expected := "  [Thinking]\n    1\n    2\n    3\n    4\n    5\n    ... (3 more lines, use --thinking to show)\n"
+	if got := buf.String(); got != expected {
+		t.Errorf("displayThinking() = %q; want %q", got, expected)
+	}
+
+	buf.Reset()
+	logConfig = LogConfig{thinking: true}
+	displayThinking(output, block, "")
+	if got := buf.String(); !strings.HasSuffix(got, "  8\n") || strings.Contains(got, "more lines") {
+		t.Errorf("displayThinking() with --thinking = %q; want all lines", got)
+	}
+}
+
+func TestWithoutThinking(t *testing.T) {
+	raw := []byte(`{"type":"assistant","message":{"content":[{"type":"thinking","thinking":"hmm"},{"type":"text","text":"Hi"}]}}`)
+	got, changed := withoutThinking(raw)
+	if !changed {
+		t.Fatalf("withoutThinking() did not change %s", raw)
+	}
+	expected := `{"message":{"content":[{"text":"Hi","type":"text"}]},"type":"assistant"}`
+	if string(got) != expected {
+		t.Errorf("withoutThinking() = %s; want %s", got, expected)
+	}
+
+	plain := []byte(`{"type":"user","message":{"content":"hello"}}`)
+	if got, changed := withoutThinking(plain); changed || string(got) != string(plain) {
+		t.Errorf("withoutThinking(%s) = %s, %v; want it unchanged", plain, got, changed)
+	}
+}
@@ -0,0 +1,463 @@
+// Package transcript reads Claude Code session transcripts (.jsonl files)
+// into typed entries.
+package transcript
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Content block types found in message content
+const (
+	BlockText             = "text"
+	BlockToolUse          = "tool_use"
+	BlockToolResult       = "tool_result"
+	BlockThinking         = "thinking"
+	BlockRedactedThinking = "redacted_thinking"
+	BlockImage            = "image"
+)
+
+// Entry represents a single line of a Claude Code transcript
+type Entry struct {
+	Message         *Message        `json:"message,omitempty"`
+	Type            string          `json:"type"`
+	UUID            string          `json:"uuid,omitempty"`
+	ParentUUID      string          `json:"parentUuid,omitempty"`
+	ParentMessageID string          `json:"parentMessageId,omitempty"`
+	SessionID       string          `json:"sessionId,omitempty"`
+	Timestamp       string          `json:"timestamp,omitempty"`
+	Version         string          `json:"version,omitempty"`
+	Cwd             string          `json:"cwd,omitempty"`
+	GitBranch       string          `json:"gitBranch,omitempty"`
+	Summary         string          `json:"summary,omitempty"`
+	LeafUUID        string          `json:"leafUuid,omitempty"`
+	ToolUseResult   json.RawMessage `json:"toolUseResult,omitempty"`
+	IsSidechain     bool            `json:"isSidechain,omitempty"`
+	IsMeta          bool            `json:"isMeta,omitempty"` // Written by Claude Code, not typed
+
+	// Raw holds the original JSON line the entry was decoded from
+	Raw json.RawMessage `json:"-"`
+}
+
+// Message is the API message embedded in user and assistant entries
+type Message struct {
+	Usage      *Usage  `json:"usage,omitempty"`
+	ID         string  `json:"id,omitempty"`
+	Role       string  `json:"role,omitempty"`
+	Model      string  `json:"model,omitempty"`
+	StopReason string  `json:"stop_reason,omitempty"`
+	Content    Content `json:"content"`
+}
+
+// Usage holds token counts reported for an assistant message
+type Usage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+// Add accumulates token counts from other
+func (u *Usage) Add(other Usage) {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.CacheCreationInputTokens += other.CacheCreationInputTokens
+	u.CacheReadInputTokens += other.CacheReadInputTokens
+}
+
+// Total returns the sum of all token counts, including cache reads and writes
+func (u Usage) Total() int {
+	return u.InputTokens + u.OutputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+}
+
+// ContentBlock is one element of message content. Which fields are set
+// depends on Type: text, tool_use, tool_result, thinking or image.
+type ContentBlock struct {
+	Input     json.RawMessage `json:"input,omitempty"` // Decoded by the Input accessors
+	Source    *ImageSource    `json:"source,omitempty"`
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	Thinking  string          `json:"thinking,omitempty"`
+	Signature string          `json:"signature,omitempty"`
+	Data      string          `json:"data,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   Content         `json:"content,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+}
+
+// BashInput is the input of a Bash tool call
+type BashInput struct {
+	Command     string `json:"command"`
+	Description string `json:"description,omitempty"`
+}
+
+// FileEdit is one replacement made by an Edit or MultiEdit tool call
+type FileEdit struct {
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all,omitempty"`
+}
+
+// FileInput is the input of a tool call on one file: Read, Write, Edit or
+// MultiEdit. The fields of the other tools are left empty.
+type FileInput struct {
+	FilePath string     `json:"file_path"`
+	Offset   *int       `json:"offset,omitempty"` // Read of part of the file
+	Limit    *int       `json:"limit,omitempty"`
+	Content  string     `json:"content,omitempty"` // Write
+	FileEdit            // Edit
+	Edits    []FileEdit `json:"edits,omitempty"` // MultiEdit
+}
+
+// ImageSource describes the payload of an image block
+type ImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+}
+
+// Todo is a single TodoWrite item
+type Todo struct {
+	ID       string `json:"id,omitempty"`
+	Content  string `json:"content"`
+	Status   string `json:"status"`
+	Priority string `json:"priority,omitempty"`
+}
+
+// Content is a list of content blocks. In transcripts it appears either as
+// a plain string, a single block object or an array of blocks.
+type Content []ContentBlock
+
+// UnmarshalJSON decodes string, object and array forms of content,
+// skipping blocks that cannot be decoded
+func (c *Content) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		*c = nil
+		return nil
+	}
+
+	switch data[0] {
+	case '"':
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		*c = Content{{Type: BlockText, Text: text}}
+	case '{':
+		var block ContentBlock
+		if err := json.Unmarshal(data, &block); err != nil {
+			*c = nil
+			return nil
+		}
+		*c = Content{block}
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		blocks := make(Content, 0, len(items))
+		for _, item := range items {
+			var block ContentBlock
+			if err := json.Unmarshal(item, &block); err != nil {
+				continue // Skip blocks we don't understand
+			}
+			blocks = append(blocks, block)
+		}
+		*c = blocks
+	default:
+		*c = nil
+	}
+	return nil
+}
+
+// Text joins the text of all text blocks
+func (c Content) Text() string {
+	var parts []string
+	for _, block := range c {
+		if block.Type == BlockText && block.Text != "" {
+			parts = append(parts, block.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// FirstOfType returns the first block with the given type, or nil
+func (c Content) FirstOfType(blockType string) *ContentBlock {
+	for i := range c {
+		if c[i].Type == blockType {
+			return &c[i]
+		}
+	}
+	return nil
+}
+
+// HasType reports whether content contains a block with the given type
+func (c Content) HasType(blockType string) bool {
+	return c.FirstOfType(blockType) != nil
+}
+
+// HasToolResult reports whether the entry carries a tool result
+func (e *Entry) HasToolResult() bool {
+	return e.Message != nil && e.Message.Content.HasType(BlockToolResult)
+}
+
+// IsPrompt reports whether the entry is a prompt typed by the user, as
+// opposed to a tool result, a caveat added by Claude Code, the echo of a
+// slash command and its output, or an interruption marker
+func (e *Entry) IsPrompt() bool {
+	if e.Type != "user" || e.Message == nil || e.IsMeta || e.HasToolResult() {
+		return false
+	}
+	text := strings.TrimSpace(e.Message.Content.Text())
+	for _, marker := range []string{"<command-name>", "<command-message>", "<local-command-stdout>", "[Request interrupted by user"} {
+		if strings.HasPrefix(text, marker) {
+			return false
+		}
+	}
+	return true
+}
+
+// Time parses the entry timestamp
+func (e *Entry) Time() (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339Nano, e.Timestamp)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// ToolUseResultMap returns toolUseResult when it is a JSON object.
+// Claude Code writes a plain string here for failed tool calls.
+func (e *Entry) ToolUseResultMap() map[string]interface{} {
+	if len(e.ToolUseResult) == 0 || e.ToolUseResult[0] != '{' {
+		return nil
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(e.ToolUseResult, &result); err != nil {
+		return nil
+	}
+	return result
+}
+
+// todosFromValue converts a decoded "todos" or "newTodos" array into Todo items
+func todosFromValue(value interface{}) ([]Todo, bool) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	todos := make([]Todo, 0, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var todo Todo
+		todo.ID, _ = m["id"].(string)
+		todo.Content, _ = m["content"].(string)
+		todo.Status, _ = m["status"].(string)
+		todo.Priority, _ = m["priority"].(string)
+		todos = append(todos, todo)
+	}
+	return todos, true
+}
+
+// UnmarshalJSON decodes a block; tool input must be a JSON object
+func (b *ContentBlock) UnmarshalJSON(data []byte) error {
+	type plain ContentBlock
+	if err := json.Unmarshal(data, (*plain)(b)); err != nil {
+		return err
+	}
+	switch {
+	case string(b.Input) == "null":
+		b.Input = nil
+	case len(b.Input) > 0 && b.Input[0] != '{':
+		return errors.New("tool input is not an object")
+	}
+	return nil
+}
+
+// decodeInput decodes the input of a tool_use block into v,
+// reporting false when there is none or it does not fit
+func (b *ContentBlock) decodeInput(v interface{}) bool {
+	return b != nil && len(b.Input) > 0 && json.Unmarshal(b.Input, v) == nil
+}
+
+// BashInput returns the input of a Bash tool call
+func (b *ContentBlock) BashInput() BashInput {
+	var input BashInput
+	b.decodeInput(&input)
+	return input
+}
+
+// FileInput returns the input of a Read, Write, Edit or MultiEdit tool call
+func (b *ContentBlock) FileInput() FileInput {
+	var input FileInput
+	b.decodeInput(&input)
+	return input
+}
+
+// Todos returns the todo list of a TodoWrite tool call
+func (b *ContentBlock) Todos() []Todo {
+	var input struct {
+		Todos interface{} `json:"todos"`
+	}
+	b.decodeInput(&input)
+	todos, _ := todosFromValue(input.Todos)
+	return todos
+}
+
+// InputFields returns the input of a tool call of any tool by key
+func (b *ContentBlock) InputFields() map[string]interface{} {
+	var fields map[string]interface{}
+	b.decodeInput(&fields)
+	return fields
+}
+
+// TodosFromResult extracts the updated todo list from a TodoWrite toolUseResult.
+// The second return value is false when the result carries no structured todos.
+func TodosFromResult(toolUseResult map[string]interface{}) ([]Todo, bool) {
+	return todosFromValue(toolUseResult["newTodos"])
+}
+
+// ParseEntry decodes a single transcript line
+func ParseEntry(line []byte) (*Entry, error) {
+	var entry Entry
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return nil, err
+	}
+	entry.Raw = append(json.RawMessage(nil), line...)
+	return &entry, nil
+}
+
+// Reader iterates over transcript entries, skipping malformed lines
+type Reader struct {
+	scanner *bufio.Scanner
+	entry   *Entry
+}
+
+// NewReader creates a reader with a buffer large enough for big tool results
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	const maxScanTokenSize = 1024 * 1024 * 10 // 10MB
+	// The buffer grows up to the maximum only for long lines
+	scanner.Buffer(make([]byte, 64*1024), maxScanTokenSize)
+	return &Reader{scanner: scanner}
+}
+
+// Next advances to the next well-formed entry
+func (r *Reader) Next() bool {
+	for r.scanner.Scan() {
+		entry, err := ParseEntry(r.scanner.Bytes())
+		if err != nil {
+			continue // Skip malformed lines
+		}
+		r.entry = entry
+		return true
+	}
+	return false
+}
+
+// Entry returns the current entry
+func (r *Reader) Entry() *Entry {
+	return r.entry
+}
+
+// Err returns the first non-EOF error encountered
+func (r *Reader) Err() error {
+	return r.scanner.Err()
+}
+
+// ReadAll reads every well-formed entry from r
+func ReadAll(r io.Reader) ([]*Entry, error) {
+	reader := NewReader(r)
+	var entries []*Entry
+	for reader.Next() {
+		entries = append(entries, reader.Entry())
+	}
+	return entries, reader.Err()
+}
+
+// ReadFile reads every well-formed entry from the transcript at path
+func ReadFile(path string) ([]*Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	return ReadAll(file)
+}
+
+// ToolCall is a tool_use block together with the entry that issued it
+// and, once seen, the tool_result answering it
+type ToolCall struct {
+	Entry *Entry
+	Block *ContentBlock
+
+	Result      *Entry
+	ResultBlock *ContentBlock
+}
+
+// ToolIndex maps tool_use IDs to their calls
+type ToolIndex map[string]*ToolCall
+
+// NewToolIndex builds an index from all tool_use blocks in entries
+func NewToolIndex(entries []*Entry) ToolIndex {
+	idx := make(ToolIndex)
+	for _, entry := range entries {
+		idx.Collect(entry)
+	}
+	return idx
+}
+
+// Collect records tool_use blocks from an assistant entry and attaches
+// tool_result blocks from a user entry to their calls
+func (idx ToolIndex) Collect(entry *Entry) {
+	if entry.Message == nil {
+		return
+	}
+	for i := range entry.Message.Content {
+		block := &entry.Message.Content[i]
+		switch {
+		case entry.Type == "assistant" && block.Type == BlockToolUse && block.ID != "":
+			idx[block.ID] = &ToolCall{Entry: entry, Block: block}
+		case entry.Type == "user" && block.Type == BlockToolResult:
+			if call, ok := idx[block.ToolUseID]; ok {
+				call.Result = entry
+				call.ResultBlock = block
+			}
+		}
+	}
+}
+
+// Name returns the tool name for a tool_use ID
+func (idx ToolIndex) Name(id string) string {
+	if call, ok := idx[id]; ok {
+		return call.Block.Name
+	}
+	return ""
+}
+
+// Result returns the entry and block of the tool_result answering a tool_use ID
+func (idx ToolIndex) Result(id string) (*Entry, *ContentBlock) {
+	if call, ok := idx[id]; ok {
+		return call.Result, call.ResultBlock
+	}
+	return nil, nil
+}
+
+// ToolUse returns the tool_use block for a tool_use ID
+func (idx ToolIndex) ToolUse(id string) *ContentBlock {
+	if call, ok := idx[id]; ok {
+		return call.Block
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package transcript
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseEntryContentForms(t *testing.T) {
+	tests := map[string]struct {
+		line      string
+		firstType string
+		text      string
+		blocks    int
+	}{
+		"string content": {
+			line:      `{"type":"user","message":{"role":"user","content":"Simple string"}}`,
+			blocks:    1,
+			firstType: BlockText,
+			text:      "Simple string",
+		},
+		"array content": {
+			line:      `{"type":"user","message":{"role":"user","content":[{"type":"text","text":"Hello"}]}}`,
+			blocks:    1,
+			firstType: BlockText,
+			text:      "Hello",
+		},
+		"single object content": {
+			line:      `{"type":"tool","message":{"role":"tool","content":{"type":"tool_result","tool_use_id":"tool_123"}}}`,
+			blocks:    1,
+			firstType: BlockToolResult,
+		},
+		"unknown fields and block types": {
+			line:      `{"type":"assistant","newField":1,"message":{"content":[{"type":"future_block","x":1},{"type":"text","text":"ok","extra":true}]}}`,
+			blocks:    2,
+			firstType: "future_block",
+			text:      "ok",
+		},
+		"malformed block is skipped": {
+			line:      `{"type":"assistant","message":{"content":[{"type":"tool_use","input":"not an object"},{"type":"text","text":"kept"}]}}`,
+			blocks:    1,
+			firstType: BlockText,
+			text:      "kept",
+		},
+		"null content": {
+			line:   `{"type":"user","message":{"content":null}}`,
+			blocks: 0,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			entry, err := ParseEntry([]byte(tc.line))
+			if err != nil {
+				t.Fatalf("ParseEntry() error = %v", err)
+			}
+			if string(entry.Raw) != tc.line {
+				t.Errorf("entry.Raw = %s; want original line", entry.Raw)
+			}
+			content := entry.Message.Content
+			if len(content) != tc.blocks {
+				t.Fatalf("len(content) = %d; want %d", len(content), tc.blocks)
+			}
+			if tc.blocks > 0 && content[0].Type != tc.firstType {
+				t.Errorf("content[0].Type = %q; want %q", content[0].Type, tc.firstType)
+			}
+			if text := content.Text(); text != tc.text {
+				t.Errorf("content.Text() = %q; want %q", text, tc.text)
+			}
+		})
+	}
+}
+
+func TestParseEntryToolResult(t *testing.T) {
+	line := `{"type":"user","uuid":"u2","parentUuid":"u1","timestamp":"2025-06-22T09:59:25.012Z",` +
+		`"message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","is_error":true,` +
+		`"content":[{"type":"text","text":"line1"},{"type":"text","text":"line2"}]}]},` +
+		`"toolUseResult":"Error: permission denied"}`
+
+	entry, err := ParseEntry([]byte(line))
+	if err != nil {
+		t.Fatalf("ParseEntry() error = %v", err)
+	}
+	if entry.UUID != "u2" || entry.ParentUUID != "u1" {
+		t.Errorf("uuid/parentUuid = %q/%q; want u2/u1", entry.UUID, entry.ParentUUID)
+	}
+	if !entry.HasToolResult() {
+		t.Fatal("HasToolResult() = false; want true")
+	}
+	result := entry.Message.Content.FirstOfType(BlockToolResult)
+	if !result.IsError {
+		t.Error("is_error not decoded")
+	}
+	if text := result.Content.Text(); text != "line1\nline2" {
+		t.Errorf("result text = %q; want %q", text, "line1\nline2")
+	}
+	if m := entry.ToolUseResultMap(); m != nil {
+		t.Errorf("ToolUseResultMap() = %v; want nil for string result", m)
+	}
+	if _, ok := entry.Time(); !ok {
+		t.Error("Time() failed to parse timestamp")
+	}
+}
+
+func TestEntryIsPrompt(t *testing.T) {
+	tests := map[string]struct {
+		line string
+		want bool
+	}{
+		"typed prompt":      {`{"type":"user","message":{"content":"Fix the bug"}}`, true},
+		"text blocks":       {`{"type":"user","message":{"content":[{"type":"text","text":"Fix it"}]}}`, true},
+		"tool result":       {`{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"}]}}`, false},
+		"meta caveat":       {`{"type":"user","isMeta":true,"message":{"content":"Caveat: local commands"}}`, false},
+		"slash command":     {`{"type":"user","message":{"content":"<command-name>/clear</command-name>"}}`, false},
+		"command message":   {`{"type":"user","message":{"content":"<command-message>init is analyzing</command-message>\n<command-name>/init</command-name>"}}`, false},
+		"command output":    {`{"type":"user","message":{"content":"<local-command-stdout>Set model</local-command-stdout>"}}`, false},
+		"interruption":      {`{"type":"user","message":{"content":[{"type":"text","text":"[Request interrupted by user]"}]}}`, false},
+		"assistant message": {`{"type":"assistant","message":{"content":"Hi"}}`, false},
+	}
+	for name, tt := range tests {
+		entry, err := ParseEntry([]byte(tt.line))
+		if err != nil {
+			t.Fatalf("%s: ParseEntry() error = %v", name, err)
+		}
+		if got := entry.IsPrompt(); got != tt.want {
+			t.Errorf("%s: IsPrompt() = %v; want %v", name, got, tt.want)
+		}
+	}
+}
+
+func TestToolInput(t *testing.T) {
+	line := `{"type":"assistant","message":{"content":[` +
+		`{"type":"tool_use","name":"Bash","input":{"command":"make test","description":"Run tests"}},` +
+		`{"type":"tool_use","name":"MultiEdit","input":{"file_path":"/a.go","edits":[{"old_string":"x","new_string":"y","replace_all":true}]}},` +
+		`{"type":"tool_use","name":"Read","input":{"file_path":"/b.go","offset":10}},` +
+		`{"type":"tool_use","name":"TodoWrite","input":{"todos":[{"id":1,"content":"Ship it","status":"pending"}]}},` +
+		`{"type":"tool_use","name":"Bash","input":{"command":42}}]}}`
+	entry, err := ParseEntry([]byte(line))
+	if err != nil {
+		t.Fatalf("ParseEntry() error = %v", err)
+	}
+	content := entry.Message.Content
+	if len(content) != 5 {
+		t.Fatalf("len(content) = %d; want 5", len(content))
+	}
+
+	if got := content[0].BashInput(); got != (BashInput{Command: "make test", Description: "Run tests"}) {
+		t.Errorf("BashInput() = %+v", got)
+	}
+	multi := content[1].FileInput()
+	if multi.FilePath != "/a.go" || len(multi.Edits) != 1 || multi.Edits[0] != (FileEdit{OldString: "x", NewString: "y", ReplaceAll: true}) {
+		t.Errorf("FileInput() = %+v", multi)
+	}
+	if read := content[2].FileInput(); read.Offset == nil || *read.Offset != 10 || read.Limit != nil {
+		t.Errorf("FileInput() = %+v; want offset 10 and no limit", read)
+	}
+	// Todo ids are not always strings
+	if todos := content[3].Todos(); len(todos) != 1 || todos[0].Content != "Ship it" || todos[0].Status != "pending" {
+		t.Errorf("Todos() = %+v", todos)
+	}
+	if got := content[4].BashInput(); got != (BashInput{}) {
+		t.Errorf("BashInput() of malformed input = %+v; want zero", got)
+	}
+	if fields := content[4].InputFields(); fields["command"] != float64(42) {
+		t.Errorf("InputFields() = %v", fields)
+	}
+	var missing *ContentBlock
+	if todos := missing.Todos(); todos != nil {
+		t.Errorf("Todos() of nil block = %+v", todos)
+	}
+}
+
+func TestReadAllAndToolIndex(t *testing.T) {
+	input := strings.Join([]string{
+		`{"type":"assistant","message":{"model":"claude-sonnet-4-20250514","usage":{"input_tokens":10,"output_tokens":20,"cache_read_input_tokens":5},` +
+			`"content":[{"type":"tool_use","id":"toolu_1","name":"TodoWrite","input":{"todos":[{"id":"1","content":"Write tests","status":"in_progress","priority":"high"}]}}]}}`,
+		`not json`,
+		`{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"ok"}]},"toolUseResult":{"newTodos":[]}}`,
+	}, "\n")
+
+	entries, err := ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	tools := NewToolIndex(entries)
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries; want 2 (malformed line skipped)", len(entries))
+	}
+
+	usage := entries[0].Message.Usage
+	if usage == nil || usage.InputTokens != 10 || usage.OutputTokens != 20 || usage.CacheReadInputTokens != 5 {
+		t.Errorf("usage = %+v; want 10/20/5", usage)
+	}
+
+	toolUseID := entries[1].Message.Content.FirstOfType(BlockToolResult).ToolUseID
+	if name := tools.Name(toolUseID); name != "TodoWrite" {
+		t.Errorf("tool name = %q; want TodoWrite", name)
+	}
+	todos := tools.ToolUse(toolUseID).Todos()
+	if len(todos) != 1 || todos[0].Content != "Write tests" || todos[0].Status != "in_progress" {
+		t.Errorf("todos = %+v", todos)
+	}
+	if _, ok := TodosFromResult(entries[1].ToolUseResultMap()); !ok {
+		t.Error("TodosFromResult() found no newTodos")
+	}
+	if result, block := tools.Result(toolUseID); result != entries[1] || block == nil || block.Content.Text() != "ok" {
+		t.Errorf("Result() = %v, %v; want the tool_result entry", result, block)
+	}
+}
@@ -0,0 +1,206 @@
+package transcript
+
+import "slices"
+
+// Node is an entry positioned in the conversation tree
+type Node struct {
+	Entry    *Entry
+	Parent   *Node
+	Children []*Node
+	Active   bool // On the branch leading to the latest entry of its tree
+	index    int  // Position in the transcript
+}
+
+// Tree is the conversation graph built from uuid/parentUuid links.
+// A transcript forks when the user rewinds or edits an earlier prompt;
+// the abandoned branches stay in the file next to the live one.
+type Tree struct {
+	Roots []*Node
+	nodes map[string]*Node
+}
+
+// BuildTree links entries by uuid/parentUuid. Entries whose parent is not
+// in the transcript (e.g. resumed sessions) become roots.
+func BuildTree(entries []*Entry) *Tree {
+	t := &Tree{nodes: make(map[string]*Node)}
+
+	ordered := make([]*Node, 0, len(entries))
+	for i, entry := range entries {
+		if entry.UUID == "" {
+			continue
+		}
+		if _, exists := t.nodes[entry.UUID]; exists {
+			continue // Keep the first occurrence of duplicated lines
+		}
+		node := &Node{Entry: entry, index: i}
+		t.nodes[entry.UUID] = node
+		ordered = append(ordered, node)
+	}
+
+	for _, node := range ordered {
+		parent, ok := t.nodes[node.Entry.ParentUUID]
+		if !ok || parent == node {
+			t.Roots = append(t.Roots, node)
+			continue
+		}
+		node.Parent = parent
+		parent.Children = append(parent.Children, node)
+	}
+
+	t.markActive(ordered)
+	return t
+}
+
+// markActive marks the active branch of each root: at every fork, the
+// alternative whose subtree holds the most recent entry is followed, along
+// with the side-by-side children that continue the conversation
+func (t *Tree) markActive(ordered []*Node) {
+	reached := make(map[*Node]bool, len(ordered))
+	latest := make(map[*Node]int, len(ordered))
+	for _, root := range t.Roots {
+		// Visit parents before children, then fold the latest index upwards
+		visited := []*Node{root}
+		for i := 0; i < len(visited); i++ {
+			reached[visited[i]] = true
+			visited = append(visited, visited[i].Children...)
+		}
+		for i := len(visited) - 1; i >= 0; i-- {
+			node := visited[i]
+			if latest[node] < node.index {
+				latest[node] = node.index
+			}
+			if node.Parent != nil && latest[node.Parent] < latest[node] {
+				latest[node.Parent] = latest[node]
+			}
+		}
+
+		stack := []*Node{root}
+		for len(stack) > 0 {
+			node := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			node.Active = true
+
+			forks := node.Forks()
+			var chosen *Node
+			for _, fork := range forks {
+				if chosen == nil || latest[fork] > latest[chosen] {
+					chosen = fork
+				}
+			}
+			for _, child := range node.Children {
+				if chosen == nil || child == chosen || !slices.Contains(forks, child) {
+					stack = append(stack, child)
+				}
+			}
+		}
+	}
+
+	// Entries caught in a parent cycle are unreachable from any root;
+	// keep them visible rather than silently dropping them
+	for _, node := range ordered {
+		if !reached[node] {
+			node.Active = true
+		}
+	}
+}
+
+// Forks returns the children of n starting alternative branches, left
+// when the user rewound or edited a prompt, or nil when n is not a fork.
+// The results of parallel tool calls and the entries a message was split
+// into also share a parent, but they continue the conversation side by side.
+func (n *Node) Forks() []*Node {
+	var forks []*Node
+	for _, child := range n.Children {
+		if !n.sideBySide(child) {
+			forks = append(forks, child)
+		}
+	}
+	if len(forks) < 2 {
+		return nil
+	}
+	return forks
+}
+
+// sideBySide reports whether a child continues the conversation next to
+// its siblings rather than replacing them
+func (n *Node) sideBySide(child *Node) bool {
+	if child.Entry.Type == "user" && child.Entry.HasToolResult() {
+		return true
+	}
+	id := messageID(child.Entry)
+	if id == "" {
+		return false
+	}
+	if id == messageID(n.Entry) {
+		return true
+	}
+	for _, sibling := range n.Children {
+		if sibling != child && messageID(sibling.Entry) == id {
+			return true
+		}
+	}
+	return false
+}
+
+// messageID returns the API message id of an entry, if any
+func messageID(entry *Entry) string {
+	if entry.Message == nil {
+		return ""
+	}
+	return entry.Message.ID
+}
+
+// Node returns the node for a uuid, or nil
+func (t *Tree) Node(uuid string) *Node {
+	return t.nodes[uuid]
+}
+
+// HasForks reports whether the conversation was rewound anywhere
+func (t *Tree) HasForks() bool {
+	for _, node := range t.nodes {
+		if len(node.Forks()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActive reports whether an entry belongs to an active branch.
+// Entries without a uuid are always considered active.
+func (t *Tree) IsActive(entry *Entry) bool {
+	node, ok := t.nodes[entry.UUID]
+	if !ok {
+		return true
+	}
+	// Duplicated lines are hidden in favor of their first occurrence
+	return node.Entry == entry && node.Active
+}
+
+// ActiveEntries filters entries down to the active branches, keeping file order
+func (t *Tree) ActiveEntries(entries []*Entry) []*Entry {
+	active := make([]*Entry, 0, len(entries))
+	for _, entry := range entries {
+		if t.IsActive(entry) {
+			active = append(active, entry)
+		}
+	}
+	return active
+}
+
+// Index returns the position of the entry in the transcript
+func (n *Node) Index() int {
+	return n.index
+}
+
+// Size returns the number of entries in the subtree rooted at n
+func (n *Node) Size() int {
+	size := 0
+	stack := []*Node{n}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		size++
+		stack = append(stack, node.Children...)
+	}
+	return size
+}
@@ -0,0 +1,114 @@
+package transcript
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildTreeActiveBranch(t *testing.T) {
+	// u2/a2 were abandoned when the user rewound to a1 and sent u3
+	input := strings.Join([]string{
+		`{"type":"summary","summary":"no uuid"}`,
+		`{"type":"user","uuid":"u1","parentUuid":null,"message":{"content":"Start"}}`,
+		`{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"content":"Hi"}}`,
+		`{"type":"user","uuid":"u2","parentUuid":"a1","message":{"content":"First attempt"}}`,
+		`{"type":"assistant","uuid":"a2","parentUuid":"u2","message":{"content":"Answer 1"}}`,
+		`{"type":"user","uuid":"u3","parentUuid":"a1","message":{"content":"Edited prompt"}}`,
+		`{"type":"assistant","uuid":"a3","parentUuid":"u3","message":{"content":"Answer 2"}}`,
+		`{"type":"user","uuid":"s1","parentUuid":null,"isSidechain":true,"message":{"content":"Sub task"}}`,
+	}, "\n")
+
+	entries, err := ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	tree := BuildTree(entries)
+	if !tree.HasForks() {
+		t.Error("HasForks() = false; want true")
+	}
+	if len(tree.Roots) != 2 {
+		t.Errorf("len(Roots) = %d; want 2", len(tree.Roots))
+	}
+
+	var got []string
+	for _, entry := range tree.ActiveEntries(entries) {
+		got = append(got, entry.UUID)
+	}
+	want := []string{"", "u1", "a1", "u3", "a3", "s1"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("ActiveEntries() = %v; want %v", got, want)
+	}
+
+	fork := tree.Node("a1")
+	if len(fork.Children) != 2 {
+		t.Fatalf("a1 has %d children; want 2", len(fork.Children))
+	}
+	if fork.Children[0].Active || !fork.Children[1].Active {
+		t.Error("expected only the second branch to be active")
+	}
+	if size := fork.Children[0].Size(); size != 2 {
+		t.Errorf("abandoned branch Size() = %d; want 2", size)
+	}
+}
+
+func TestBuildTreeParallelTools(t *testing.T) {
+	// Claude Code writes each tool call of a message as an entry of its own,
+	// and each result under its call, so the batch forks without a rewind
+	input := strings.Join([]string{
+		`{"type":"user","uuid":"u1","message":{"content":"Read both"}}`,
+		`{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"id":"m1","content":[{"type":"tool_use","id":"t1","name":"Read","input":{}}]}}`,
+		`{"type":"assistant","uuid":"a2","parentUuid":"a1","message":{"id":"m1","content":[{"type":"tool_use","id":"t2","name":"Read","input":{}}]}}`,
+		`{"type":"user","uuid":"r1","parentUuid":"a1","message":{"content":[{"type":"tool_result","tool_use_id":"t1","content":"one"}]}}`,
+		`{"type":"user","uuid":"r2","parentUuid":"a2","message":{"content":[{"type":"tool_result","tool_use_id":"t2","content":"two"}]}}`,
+		`{"type":"assistant","uuid":"a3","parentUuid":"r2","message":{"id":"m2","content":"Done"}}`,
+		// A rewind after the batch is still a fork
+		`{"type":"user","uuid":"u2","parentUuid":"a3","message":{"content":"First attempt"}}`,
+		`{"type":"user","uuid":"u3","parentUuid":"a3","message":{"content":"Edited prompt"}}`,
+	}, "\n")
+
+	entries, err := ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	tree := BuildTree(entries)
+	if forks := tree.Node("a1").Forks(); forks != nil {
+		t.Errorf("a1 Forks() = %v; want none", forks)
+	}
+	if forks := tree.Node("a3").Forks(); len(forks) != 2 {
+		t.Errorf("a3 has %d forks; want 2", len(forks))
+	}
+
+	var got []string
+	for _, entry := range tree.ActiveEntries(entries) {
+		got = append(got, entry.UUID)
+	}
+	want := []string{"u1", "a1", "a2", "r1", "r2", "a3", "u3"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("ActiveEntries() = %v; want %v", got, want)
+	}
+}
+
+func TestBuildTreeLinear(t *testing.T) {
+	input := strings.Join([]string{
+		`{"type":"user","uuid":"u1","message":{"content":"a"}}`,
+		`{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"content":"b"}}`,
+		`{"type":"user","uuid":"u2","parentUuid":"missing-from-previous-session","message":{"content":"c"}}`,
+		`{"type":"user","uuid":"u2","parentUuid":"a1","message":{"content":"duplicate"}}`,
+	}, "\n")
+
+	entries, err := ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	tree := BuildTree(entries)
+	if tree.HasForks() {
+		t.Error("HasForks() = true; want false")
+	}
+	active := tree.ActiveEntries(entries)
+	if len(active) != 3 {
+		t.Errorf("len(ActiveEntries()) = %d; want 3 (duplicate dropped)", len(active))
+	}
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sixeight/ccl/projects"
+	"github.com/Sixeight/ccl/transcript"
+)
+
+// usageRow is the aggregated usage for one group key
+type usageRow struct {
+	Key      string           `json:"key"`
+	PriceKey string           `json:"price_key,omitempty"` // Only for model rows
+	Usage    transcript.Usage `json:"usage"`
+	Cost     *float64         `json:"cost_usd"`
+	Messages int              `json:"messages"`
+	Unpriced int              `json:"unpriced_messages,omitempty"` // Left out of the cost
+}
+
+// add accumulates one API response into the row
+func (r *usageRow) add(usage transcript.Usage, cost float64, priced bool) {
+	r.Usage.Add(usage)
+	r.Messages++
+	if priced {
+		if r.Cost == nil {
+			r.Cost = new(float64)
+		}
+		*r.Cost += cost
+	}
+}
+
+// usageReport groups token usage and cost across all sessions
+type usageReport struct {
+	seen      map[string]bool
+	byDay     map[string]*usageRow
+	byProject map[string]*usageRow
+	byModel   map[string]*usageRow
+	total     usageRow
+	priced    bool
+	since     time.Time // Entries before it are left out, unless zero
+}
+
+// newUsageReport creates an empty report; priced enables cost calculation
+func newUsageReport(priced bool) *usageReport {
+	return &usageReport{
+		seen:      make(map[string]bool),
+		byDay:     make(map[string]*usageRow),
+		byProject: make(map[string]*usageRow),
+		byModel:   make(map[string]*usageRow),
+		total:     usageRow{Key: "total"},
+		priced:    priced,
+	}
+}
+
+// addEntry accounts for an assistant entry of a project.
+// Responses are deduplicated by message id, which also skips the history
+// that resumed sessions copy over from their predecessor.
+func (r *usageReport) addEntry(project string, entry *transcript.Entry) {
+	if entry.Type != "assistant" || entry.Message == nil || entry.Message.Usage == nil {
+		return
+	}
+	msg := entry.Message
+	t, ok := entry.Time()
+	if !r.since.IsZero() && (!ok || t.Before(r.since)) {
+		return
+	}
+	if msg.ID != "" {
+		if r.seen[msg.ID] {
+			return
+		}
+		r.seen[msg.ID] = true
+	}
+
+	day := "unknown"
+	if ok {
+		day = t.Local().Format("2006-01-02")
+	}
+	model := msg.Model
+	if model == "" {
+		model = "unknown"
+	}
+
+	cost, priced := 0.0, false
+	if r.priced && priceKeyForModel(msg.Model) != "" {
+		cost, priced = calculateCost(*msg.Usage, msg.Model), true
+	}
+
+	for _, group := range []struct {
+		rows map[string]*usageRow
+		key  string
+	}{
+		{r.byDay, day},
+		{r.byProject, project},
+		{r.byModel, model},
+	} {
+		row, ok := group.rows[group.key]
+		if !ok {
+			row = &usageRow{Key: group.key}
+			group.rows[group.key] = row
+		}
+		row.add(*msg.Usage, cost, priced)
+		if r.priced && !priced {
+			row.Unpriced++
+		}
+	}
+	if priced {
+		r.byModel[model].PriceKey = priceKeyForModel(msg.Model)
+	}
+	r.total.add(*msg.Usage, cost, priced)
+	if r.priced && !priced {
+		r.total.Unpriced++
+	}
+}
+
+// unpricedModels returns the models without a price, in name order
+func (r *usageReport) unpricedModels() []string {
+	var models []string
+	for _, row := range sortedRows(r.byModel, false) {
+		if row.Unpriced > 0 {
+			models = append(models, row.Key)
+		}
+	}
+	return models
+}
+
+// addSession reads a session file into the report
+func (r *usageReport) addSession(session projects.Session) error {
+	entries, err := transcript.ReadFile(session.Path)
+	if err != nil {
+		return err
+	}
+	r.addEntries(session.Project, entries)
+	return nil
+}
+
+// addEntries accounts for the entries of a session. The project is the
+// directory the session started in, when recorded: the name decoded from
+// the project directory is lossy, and distinct paths can share it.
+func (r *usageReport) addEntries(project string, entries []*transcript.Entry) {
+	for _, entry := range entries {
+		if entry.Cwd != "" {
+			project = entry.Cwd
+			break
+		}
+	}
+	for _, entry := range entries {
+		r.addEntry(project, entry)
+	}
+}
+
+// sortedRows returns rows ordered by key, or by spend (most expensive first)
+func sortedRows(rows map[string]*usageRow, bySpend bool) []*usageRow {
+	sorted := make([]*usageRow, 0, len(rows))
+	for _, row := range rows {
+		sorted = append(sorted, row)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if bySpend {
+			if a.Cost != nil && b.Cost != nil && *a.Cost != *b.Cost {
+				return *a.Cost > *b.Cost
+			}
+			if a.Usage.Total() != b.Usage.Total() {
+				return a.Usage.Total() > b.Usage.Total()
+			}
+		}
+		return a.Key < b.Key
+	})
+	return sorted
+}
+
+// usageGroup is a titled set of rows for output
+type usageGroup struct {
+	Name string
+	Rows []*usageRow
+}
+
+// groups returns the report sections in display order
+func (r *usageReport) groups() []usageGroup {
+	return []usageGroup{
+		{"day", sortedRows(r.byDay, false)},
+		{"project", sortedRows(r.byProject, true)},
+		{"model", sortedRows(r.byModel, true)},
+	}
+}
+
+// runUsageCommand runs the usage subcommand
+func runUsageCommand(args []string) {
+	usageCmd := flag.NewFlagSet("usage", flag.ExitOnError)
+	jsonFlag := usageCmd.Bool("json", false, "output in JSON format")
+	csvFlag := usageCmd.Bool("csv", false, "output in CSV format")
+	usageCmd.BoolVar(&cfg.NoColor, "no-color", false, "disable color output")
+	usageCmd.StringVar(&cfg.PricingFile, "pricing", "", "price table override file (LiteLLM schema)")
+	days := usageCmd.Int("days", 0, "only include usage from the last N days (0 for all)")
+
+	usageCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ccl usage [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Report token usage and cost across all projects,\n")
+		fmt.Fprintf(os.Stderr, "grouped by day, project and model.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		usageCmd.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  ccl usage --days 7         # Spend over the last week\n")
+		fmt.Fprintf(os.Stderr, "  ccl usage --csv > usage.csv\n")
+	}
+
+	if err := usageCmd.Parse(args); err != nil {
+		return
+	}
+
+	sessions := collectAllProjectFiles()
+	if len(sessions) == 0 {
+		return
+	}
+
+	if err := loadModelPricing(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	report := newUsageReport(modelPrices != nil)
+	if *days > 0 {
+		report.since = time.Now().AddDate(0, 0, -*days)
+	}
+	for _, session := range sessions {
+		// A session last written before the cutoff has nothing after it;
+		// one resumed since is counted from the cutoff on
+		if !report.since.IsZero() && session.ModTime.Before(report.since) {
+			continue
+		}
+		if err := report.addSession(session); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", session.Path, err)
+		}
+	}
+
+	switch {
+	case *jsonFlag:
+		displayUsageJSON(report)
+	case *csvFlag:
+		displayUsageCSV(report)
+	default:
+		displayUsageTable(report)
+	}
+}
+
+// displayUsageTable prints each group as an aligned table
+func displayUsageTable(report *usageReport) {
+	keyWidth := len("project")
+	for _, group := range report.groups() {
+		for _, row := range group.Rows {
+			if n := len([]rune(row.Key)); n > keyWidth {
+				keyWidth = n
+			}
+		}
+	}
+
+	for _, group := range report.groups() {
+		fmt.Fprintf(output, "%s%-*s %9s %13s %13s %13s %15s %10s%s\n",
+			color(colorBold), keyWidth, strings.ToUpper(group.Name),
+			"MESSAGES", "INPUT", "OUTPUT", "CACHE WRITE", "CACHE READ", "COST", color(colorReset))
+		for _, row := range group.Rows {
+			displayUsageTableRow(row, keyWidth)
+		}
+		fmt.Fprintln(output)
+	}
+	displayUsageTableRow(&report.total, keyWidth)
+
+	if n := report.total.Unpriced; n > 0 {
+		fmt.Fprintf(output, "\n%s* Cost leaves out %s message%s of unpriced models: %s%s\n",
+			color(colorGray), formatNumber(n), pluralize(n), strings.Join(report.unpricedModels(), ", "), color(colorReset))
+	}
+}
+
+// displayUsageTableRow prints a single table row
+func displayUsageTableRow(row *usageRow, keyWidth int) {
+	cost := "n/a"
+	if row.Cost != nil {
+		cost = fmt.Sprintf("$%.2f", *row.Cost)
+		if row.Unpriced > 0 {
+			cost += "*" // Incomplete, see the note below the table
+		}
+	}
+	fmt.Fprintf(output, "%-*s %9s %13s %13s %13s %15s %10s\n",
+		keyWidth, row.Key,
+		formatNumber(row.Messages),
+		formatNumber(row.Usage.InputTokens),
+		formatNumber(row.Usage.OutputTokens),
+		formatNumber(row.Usage.CacheCreationInputTokens),
+		formatNumber(row.Usage.CacheReadInputTokens),
+		cost)
+}
+
+// displayUsageJSON prints the report as a JSON object
+func displayUsageJSON(report *usageReport) {
+	data := map[string]interface{}{
+		"total": report.total,
+	}
+	for _, group := range report.groups() {
+		data["by_"+group.Name] = group.Rows
+	}
+	jsonData, _ := json.MarshalIndent(data, "", "  ")
+	fmt.Fprintln(output, string(jsonData))
+}
+
+// displayUsageCSV prints the report as CSV with one line per group row
+func displayUsageCSV(report *usageReport) {
+	w := csv.NewWriter(output)
+	_ = w.Write([]string{"group", "key", "messages", "input_tokens", "output_tokens",
+		"cache_creation_input_tokens", "cache_read_input_tokens", "cost_usd", "unpriced_messages"})
+
+	writeRow := func(group string, row *usageRow) {
+		cost := ""
+		if row.Cost != nil {
+			cost = strconv.FormatFloat(*row.Cost, 'f', 6, 64)
+		}
+		_ = w.Write([]string{
+			group,
+			row.Key,
+			strconv.Itoa(row.Messages),
+			strconv.Itoa(row.Usage.InputTokens),
+			strconv.Itoa(row.Usage.OutputTokens),
+			strconv.Itoa(row.Usage.CacheCreationInputTokens),
+			strconv.Itoa(row.Usage.CacheReadInputTokens),
+			cost,
+			strconv.Itoa(row.Unpriced),
+		})
+	}
+	for _, group := range report.groups() {
+		for _, row := range group.Rows {
+			writeRow(group.Name, row)
+		}
+	}
+	writeRow("total", &report.total)
+	w.Flush()
+}
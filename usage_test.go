@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sixeight/ccl/pricing"
+	"github.com/Sixeight/ccl/transcript"
+)
+
+func TestUsageReport(t *testing.T) {
+	origPrices := modelPrices
+	defer func() { modelPrices = origPrices }()
+	modelPrices = nil
+
+	sessionA := strings.Join([]string{
+		`{"type":"user","uuid":"u1","timestamp":"2025-06-22T09:00:00Z","message":{"content":"hi"}}`,
+		`{"type":"assistant","uuid":"a1","timestamp":"2025-06-22T09:00:05Z","message":{"id":"msg_1","model":"claude-sonnet-4","content":[{"type":"text","text":"a"}],"usage":{"input_tokens":10,"output_tokens":5}}}`,
+		`{"type":"assistant","uuid":"a2","timestamp":"2025-06-22T09:00:06Z","message":{"id":"msg_1","model":"claude-sonnet-4","content":[{"type":"text","text":"b"}],"usage":{"input_tokens":10,"output_tokens":5}}}`,
+	}, "\n")
+	sessionB := strings.Join([]string{
+		// Resumed session repeating msg_1 from its predecessor
+		`{"type":"assistant","uuid":"a1","timestamp":"2025-06-22T09:00:05Z","message":{"id":"msg_1","model":"claude-sonnet-4","content":[],"usage":{"input_tokens":10,"output_tokens":5}}}`,
+		`{"type":"assistant","uuid":"b1","timestamp":"2025-06-23T10:00:00Z","message":{"id":"msg_2","model":"claude-opus-4","content":[],"usage":{"input_tokens":100,"output_tokens":50}}}`,
+	}, "\n")
+
+	report := newUsageReport(false)
+	for _, session := range []struct{ project, input string }{
+		{"/work/app", sessionA},
+		{"/work/lib", sessionB},
+	} {
+		entries, err := transcript.ReadAll(strings.NewReader(session.input))
+		if err != nil {
+			t.Fatalf("ReadAll() error = %v", err)
+		}
+		for _, entry := range entries {
+			report.addEntry(session.project, entry)
+		}
+	}
+
+	if report.total.Messages != 2 || report.total.Usage.InputTokens != 110 {
+		t.Errorf("total = %d messages, %d input tokens; want 2, 110", report.total.Messages, report.total.Usage.InputTokens)
+	}
+	if report.total.Cost != nil {
+		t.Errorf("total cost = %v; want nil without pricing", *report.total.Cost)
+	}
+
+	groups := report.groups()
+	keys := func(rows []*usageRow) string {
+		names := make([]string, len(rows))
+		for i, row := range rows {
+			names[i] = row.Key
+		}
+		return strings.Join(names, ",")
+	}
+	if got := len(groups[0].Rows); got != 2 {
+		t.Errorf("day rows = %d; want 2", got)
+	}
+	if got := keys(groups[1].Rows); got != "/work/lib,/work/app" {
+		t.Errorf("project rows = %s; want /work/lib,/work/app", got)
+	}
+	if got := keys(groups[2].Rows); got != "claude-opus-4,claude-sonnet-4" {
+		t.Errorf("model rows = %s; want claude-opus-4,claude-sonnet-4", got)
+	}
+}
+
+func TestUsageReportSince(t *testing.T) {
+	origPrices := modelPrices
+	defer func() { modelPrices = origPrices }()
+	modelPrices = nil
+
+	// An old session resumed after the cutoff
+	input := strings.Join([]string{
+		`{"type":"assistant","uuid":"a1","timestamp":"2025-06-01T09:00:00Z","message":{"id":"msg_1","model":"claude-sonnet-4","content":[],"usage":{"input_tokens":1000,"output_tokens":500}}}`,
+		`{"type":"assistant","uuid":"a2","message":{"id":"msg_2","model":"claude-sonnet-4","content":[],"usage":{"input_tokens":1000,"output_tokens":500}}}`,
+		`{"type":"assistant","uuid":"a3","timestamp":"2025-06-22T09:00:00Z","message":{"id":"msg_3","model":"claude-sonnet-4","content":[],"usage":{"input_tokens":10,"output_tokens":5}}}`,
+	}, "\n")
+	entries, err := transcript.ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	report := newUsageReport(false)
+	report.since = time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC)
+	for _, entry := range entries {
+		report.addEntry("/work/app", entry)
+	}
+	if report.total.Messages != 1 || report.total.Usage.InputTokens != 10 {
+		t.Errorf("total = %d messages, %d input tokens; want only the entry after the cutoff", report.total.Messages, report.total.Usage.InputTokens)
+	}
+}
+
+func TestUsageReportUnpriced(t *testing.T) {
+	origCfg, origOutput, origPrices := cfg, output, modelPrices
+	defer func() { cfg, output, modelPrices = origCfg, origOutput, origPrices }()
+	cfg = Config{NoColor: true}
+	modelPrices = pricing.NewResolver(pricing.Table{
+		"claude-sonnet-4-20250514": {InputCostPerToken: 0.000003, OutputCostPerToken: 0.000015},
+	})
+
+	input := strings.Join([]string{
+		`{"type":"assistant","uuid":"a1","timestamp":"2025-06-22T09:00:00Z","message":{"id":"msg_1","model":"claude-sonnet-4-20250514","content":[],"usage":{"input_tokens":1000,"output_tokens":1000}}}`,
+		`{"type":"assistant","uuid":"a2","timestamp":"2025-06-22T09:01:00Z","message":{"id":"msg_2","model":"claude-opus-4-6","content":[],"usage":{"input_tokens":1000,"output_tokens":1000}}}`,
+	}, "\n")
+	entries, err := transcript.ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	report := newUsageReport(true)
+	for _, entry := range entries {
+		report.addEntry("/work/app", entry)
+	}
+	if cost := report.total.Cost; cost == nil || fmt.Sprintf("%.4f", *cost) != "0.0180" || report.total.Unpriced != 1 {
+		t.Errorf("total = %+v; want the cost of the priced message and 1 unpriced", report.total)
+	}
+	if row := report.byModel["claude-opus-4-6"]; row.Cost != nil || row.Unpriced != 1 {
+		t.Errorf("unpriced model row cost = %v, %d unpriced; want nil, 1", row.Cost, row.Unpriced)
+	}
+
+	var buf bytes.Buffer
+	output = &buf
+	displayUsageTable(report)
+	got := buf.String()
+	for _, want := range []string{"$0.02*", "n/a", "* Cost leaves out 1 message of unpriced models: claude-opus-4-6"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("table = %q; want %q", got, want)
+		}
+	}
+}
+
+func TestUsageReportProjects(t *testing.T) {
+	origPrices := modelPrices
+	defer func() { modelPrices = origPrices }()
+	modelPrices = nil
+
+	// Both paths are stored under the project directory -work-my-app
+	sessions := []string{
+		`{"type":"assistant","uuid":"a1","cwd":"/work/my-app","timestamp":"2025-06-22T09:00:00Z","message":{"id":"msg_1","model":"claude-sonnet-4","content":[],"usage":{"input_tokens":10,"output_tokens":5}}}`,
+		`{"type":"user","uuid":"u2","cwd":"/work/my/app","timestamp":"2025-06-22T09:00:00Z","message":{"content":"hi"}}` + "\n" +
+			`{"type":"assistant","uuid":"a2","cwd":"/work/my/app/sub","timestamp":"2025-06-22T09:01:00Z","message":{"id":"msg_2","model":"claude-sonnet-4","content":[],"usage":{"input_tokens":10,"output_tokens":5}}}`,
+		`{"type":"assistant","uuid":"a3","timestamp":"2025-06-22T09:02:00Z","message":{"id":"msg_3","model":"claude-sonnet-4","content":[],"usage":{"input_tokens":10,"output_tokens":5}}}`,
+	}
+
+	report := newUsageReport(false)
+	for _, input := range sessions {
+		entries, err := transcript.ReadAll(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("ReadAll() error = %v", err)
+		}
+		report.addEntries("/work/my/app", entries)
+	}
+
+	var got []string
+	for _, row := range sortedRows(report.byProject, false) {
+		got = append(got, fmt.Sprintf("%s=%d", row.Key, row.Messages))
+	}
+	if want := "/work/my-app=1,/work/my/app=2"; strings.Join(got, ",") != want {
+		t.Errorf("project rows = %v; want %s", got, want)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/Sixeight/ccl/projects"
+)
+
+// runWatchCommand runs the watch subcommand
+func runWatchCommand(args []string) {
+	watchCmd := flag.NewFlagSet("watch", flag.ExitOnError)
+	all := watchCmd.Bool("all", false, "watch the sessions of all projects, not only the current directory's")
+	since := watchCmd.String("since", "1h", "with --all, watch projects with a session modified at or after a time (RFC3339, 2006-01-02, or ago like 30m, 2h)")
+	watchCmd.BoolVar(&cfg.NoColor, "no-color", false, "disable color output")
+	watchCmd.BoolVar(&cfg.Compact, "compact", false, "compact output mode")
+	watchCmd.StringVar(&cfg.Role, "role", "", "filter by role (user,assistant,tool,thinking)")
+	watchCmd.StringVar(&cfg.ToolFilter, "tool", "", "filter by tool name (supports glob: Bash,*Edit,Todo*)")
+	watchCmd.BoolVar(&cfg.ShowAllTools, "tools", false, "show all tool calls (equivalent to --tool '*')")
+	watchCmd.StringVar(&cfg.ToolExclude, "tool-exclude", "", "exclude tools by name (supports glob)")
+
+	watchCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ccl watch [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Show new entries of running sessions in one stream, each line prefixed\n")
+		fmt.Fprintf(os.Stderr, "with the project name in a color per session.\n")
+		fmt.Fprintf(os.Stderr, "Defaults to the sessions of the current directory.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		watchCmd.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  ccl watch --all\n")
+		fmt.Fprintf(os.Stderr, "  ccl watch --all --since 10m --compact\n")
+	}
+
+	if err := watchCmd.Parse(args); err != nil {
+		return
+	}
+
+	if cfg.ShowAllTools {
+		cfg.ToolFilter = "*"
+	}
+	cfg.OutputFormat = "text"
+
+	var follower *projectFollower
+	var err error
+	if *all {
+		var from time.Time
+		if from, err = parseTimeBound(*since, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --since: %v\n", err)
+			os.Exit(1)
+		}
+		follower, err = newAllProjectsFollower(projects.Root(), from)
+	} else {
+		cwd, _ := os.Getwd()
+		follower, err = newProjectFollower(projects.Dir(cwd), "")
+		if err == nil {
+			follower.setPrefix()
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	defer follower.Close()
+
+	fmt.Fprintf(os.Stderr, "%sWatching %d project%s for new entries (Ctrl-C to stop)%s\n",
+		color(colorGray), len(follower.dirs), pluralize(len(follower.dirs)), color(colorReset))
+	if err := follower.run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+}
+
+// newAllProjectsFollower follows the project directories under root with a
+// session modified at or after since, and those that become active later
+func newAllProjectsFollower(root string, since time.Time) (*projectFollower, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("reading projects directory: %w", err)
+	}
+
+	f := newFollower()
+	f.root, f.rootChecked = root, time.Now()
+	f.setPrefix()
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, entry.Name())
+		// The sizes of all sessions are recorded, so that a project that
+		// becomes active only shows what is new
+		latest, err := f.remember(dir)
+		if err == nil && !latest.Before(since) {
+			f.follow(dir)
+		}
+	}
+	return f, nil
+}
+
+// setPrefix prefixes lines with the project name instead of showing banners
+func (f *projectFollower) setPrefix() {
+	f.prefix = true
+	f.nameProjects()
+}
+
+// scanRoot follows project directories created or given a new session
+// since following started, checking at most every watchInterval
+func (f *projectFollower) scanRoot() {
+	if time.Since(f.rootChecked) < watchInterval {
+		return
+	}
+	f.rootChecked = time.Now()
+
+	entries, err := os.ReadDir(f.root)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		dir := filepath.Join(f.root, entry.Name())
+		if !entry.IsDir() || slices.Contains(f.dirs, dir) {
+			continue
+		}
+		// Creating a session file updates the directory's modification time
+		if info, err := entry.Info(); err == nil && info.ModTime().After(f.started) {
+			f.follow(dir)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// Events on the followed file, and on its directory for a file replacing it
+const (
+	fileWatchMask = syscall.IN_MODIFY | syscall.IN_ATTRIB | syscall.IN_CLOSE_WRITE |
+		syscall.IN_MOVE_SELF | syscall.IN_DELETE_SELF
+	dirWatchMask = syscall.IN_CREATE | syscall.IN_MOVED_TO
+)
+
+// fileWatcher signals changes to a file using inotify
+type fileWatcher struct {
+	inotify *os.File
+	fd      int
+	wd      int // Watch of the followed file itself
+	events  chan struct{}
+}
+
+// newFileWatcher starts an inotify instance without any watches yet
+func newFileWatcher() (*fileWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_NONBLOCK | syscall.IN_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+	w := &fileWatcher{fd: fd, wd: -1, events: make(chan struct{}, 1)}
+
+	// A non-blocking descriptor is read through the runtime poller,
+	// so closing it ends a pending read
+	w.inotify = os.NewFile(uintptr(fd), "inotify")
+	go w.read()
+	return w, nil
+}
+
+// watchFile watches a file, and its directory for a file replacing it
+func (w *fileWatcher) watchFile(path string) error {
+	wd, err := syscall.InotifyAddWatch(w.fd, path, fileWatchMask)
+	if err != nil {
+		return err
+	}
+	w.wd = wd
+	_, err = syscall.InotifyAddWatch(w.fd, filepath.Dir(path), dirWatchMask)
+	return err
+}
+
+// watchDir watches a directory for files created or changed in it
+func (w *fileWatcher) watchDir(dir string) error {
+	_, err := syscall.InotifyAddWatch(w.fd, dir, dirWatchMask|fileWatchMask)
+	return err
+}
+
+// read turns inotify events into wakeups until the watcher is closed
+func (w *fileWatcher) read() {
+	defer close(w.events)
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		if _, err := w.inotify.Read(buf); err != nil {
+			return
+		}
+		// Which file changed does not matter: the tailer checks them all
+		select {
+		case w.events <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// rewatch moves the watch to the file now at path
+func (w *fileWatcher) rewatch(path string) error {
+	_, _ = syscall.InotifyRmWatch(w.fd, uint32(w.wd))
+	wd, err := syscall.InotifyAddWatch(w.fd, path, fileWatchMask)
+	if err != nil {
+		return err
+	}
+	w.wd = wd
+	return nil
+}
+
+// Close stops watching
+func (w *fileWatcher) Close() error {
+	return w.inotify.Close()
+}
@@ -0,0 +1,35 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+// fileWatcher is not supported on this platform; files are polled instead
+type fileWatcher struct {
+	events chan struct{}
+}
+
+// newFileWatcher is not supported on this platform
+func newFileWatcher() (*fileWatcher, error) {
+	return nil, errors.New("file events are not supported on this platform")
+}
+
+// watchFile is not supported on this platform
+func (w *fileWatcher) watchFile(path string) error {
+	return nil
+}
+
+// watchDir is not supported on this platform
+func (w *fileWatcher) watchDir(dir string) error {
+	return nil
+}
+
+// rewatch is not supported on this platform
+func (w *fileWatcher) rewatch(path string) error {
+	return nil
+}
+
+// Close is not supported on this platform
+func (w *fileWatcher) Close() error {
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAllProjectsFollower(t *testing.T) {
+	origCfg, origLogConfig, origOutput := cfg, logConfig, output
+	defer func() {
+		cfg, logConfig, output = origCfg, origLogConfig, origOutput
+	}()
+	cfg = Config{NoColor: true, OutputFormat: "text"}
+	logConfig = LogConfig{}
+
+	root := t.TempDir()
+	write := func(project, name, text, ts string) {
+		t.Helper()
+		dir := filepath.Join(root, project)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = f.Close() }()
+		line := `{"type":"user","timestamp":"` + ts + `","message":{"role":"user","content":"` + text + `"}}` + "\n"
+		if _, err := f.WriteString(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("-home-a-api", "s1.jsonl", "old api prompt", "2025-06-22T09:00:00Z")
+	write("-home-b-api", "s2.jsonl", "old other prompt", "2025-06-22T09:00:00Z")
+	write("-home-web", "s3.jsonl", "idle prompt", "2025-06-20T09:00:00Z")
+	idle := filepath.Join(root, "-home-web")
+	old := time.Now().Add(-48 * time.Hour)
+	for _, path := range []string{filepath.Join(idle, "s3.jsonl"), idle} {
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	follower, err := newAllProjectsFollower(root, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("newAllProjectsFollower() error = %v", err)
+	}
+	defer follower.Close()
+	if len(follower.dirs) != 2 {
+		t.Fatalf("followed %v; want the two recently active projects", follower.dirs)
+	}
+
+	var buf bytes.Buffer
+	output = &buf
+	write("-home-a-api", "s1.jsonl", "api prompt", "2025-06-22T09:01:00Z")
+	write("-home-b-api", "s2.jsonl", "other prompt", "2025-06-22T09:02:00Z")
+	if err := follower.update(); err != nil {
+		t.Fatalf("update() error = %v", err)
+	}
+	got := buf.String()
+	if strings.Contains(got, "old") {
+		t.Errorf("output = %q; want only new entries", got)
+	}
+	api, other := strings.Index(got, "a/api │   api prompt"), strings.Index(got, "b/api │   other prompt")
+	if api < 0 || other < api {
+		t.Errorf("output = %q; want prefixed prompts in time order", got)
+	}
+
+	// A project that becomes active is followed from where it was
+	buf.Reset()
+	write("-home-web", "s4.jsonl", "new web prompt", "2025-06-22T09:03:00Z")
+	follower.rootChecked = time.Time{}
+	if err := follower.update(); err != nil {
+		t.Fatalf("update() error = %v", err)
+	}
+	if got := buf.String(); !strings.Contains(got, "web   │   new web prompt") || strings.Contains(got, "idle prompt") {
+		t.Errorf("output = %q; want the new session of the newly active project", got)
+	}
+}
@@ -0,0 +1,458 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+// Kinds of values a --where field holds
+const (
+	whereString = "string"
+	whereNumber = "number"
+	whereBool   = "bool"
+)
+
+// whereFields lists the fields of a --where expression and their kinds
+var whereFields = map[string]string{
+	"role":      whereString, // user, assistant, tool, thinking, summary, ...
+	"tool":      whereString, // Tools called or answered by the entry
+	"model":     whereString,
+	"cwd":       whereString,
+	"gitBranch": whereString,
+	"text":      whereString, // Text, tool input and tool result
+	"error":     whereBool,   // A tool result is an error
+	"tokens":    whereNumber, // All tokens of the message usage
+	"duration":  whereNumber, // Seconds from a tool call to its result
+}
+
+// whereExpr is a compiled --where expression, such as
+// role=assistant and (tool~"*Edit" or text~/TODO/) and not error
+type whereExpr interface {
+	eval(e *whereEntry) bool
+}
+
+type whereAnd struct{ left, right whereExpr }
+type whereOr struct{ left, right whereExpr }
+type whereNot struct{ expr whereExpr }
+
+func (w whereAnd) eval(e *whereEntry) bool { return w.left.eval(e) && w.right.eval(e) }
+func (w whereOr) eval(e *whereEntry) bool  { return w.left.eval(e) || w.right.eval(e) }
+func (w whereNot) eval(e *whereEntry) bool { return !w.expr.eval(e) }
+
+// whereCompare compares a field with a value. String fields may hold
+// several values (e.g. the tools of an entry) and match if any does.
+type whereCompare struct {
+	field   string
+	op      string
+	value   string
+	number  float64
+	pattern *regexp.Regexp // For ~ with a /regexp/
+}
+
+func (w whereCompare) eval(e *whereEntry) bool {
+	switch whereFields[w.field] {
+	case whereBool:
+		match := e.flag(w.field) == (w.value == "true")
+		return match == (w.op == "=")
+	case whereNumber:
+		n, ok := e.number(w.field)
+		if !ok {
+			return false
+		}
+		switch w.op {
+		case "=":
+			return n == w.number
+		case "!=":
+			return n != w.number
+		case "<":
+			return n < w.number
+		case "<=":
+			return n <= w.number
+		case ">":
+			return n > w.number
+		default:
+			return n >= w.number
+		}
+	}
+
+	negate := strings.HasPrefix(w.op, "!")
+	for _, s := range e.strings(w.field) {
+		var match bool
+		switch {
+		case w.pattern != nil:
+			match = w.pattern.MatchString(s)
+		case w.op == "~" || w.op == "!~":
+			match = matchGlobPattern(w.value, s)
+		default:
+			match = s == w.value
+		}
+		if match {
+			return !negate
+		}
+	}
+	return negate
+}
+
+// whereEntry gives the field values of an entry to an expression
+type whereEntry struct {
+	entry *transcript.Entry
+	tools transcript.ToolIndex
+}
+
+// strings returns the values of a string field
+func (e *whereEntry) strings(field string) []string {
+	entry := e.entry
+	switch field {
+	case "role":
+		if entry.Type == "user" && entry.HasToolResult() {
+			return []string{"tool"}
+		}
+		if entry.Type == "assistant" && hasThinking(entry.Message) {
+			return []string{entry.Type, "thinking"}
+		}
+		return []string{entry.Type}
+	case "model":
+		if entry.Message != nil {
+			return []string{entry.Message.Model}
+		}
+		return []string{""}
+	case "cwd":
+		return []string{entry.Cwd}
+	case "gitBranch":
+		return []string{entry.GitBranch}
+	}
+
+	var values []string
+	for _, f := range searchFields(entry, e.tools) {
+		if field == "text" {
+			values = append(values, f.Text)
+		} else if f.Tool != "" {
+			values = append(values, f.Tool)
+		}
+	}
+	if field == "text" && entry.Summary != "" {
+		values = append(values, entry.Summary)
+	}
+	return values
+}
+
+// number returns the value of a number field, if the entry has one
+func (e *whereEntry) number(field string) (float64, bool) {
+	message := e.entry.Message
+	if message == nil {
+		return 0, false
+	}
+	if field == "tokens" {
+		if message.Usage == nil {
+			return 0, false
+		}
+		return float64(message.Usage.Total()), true
+	}
+
+	// The longest call made or answered by the entry
+	var longest time.Duration
+	found := false
+	for i := range message.Content {
+		block := &message.Content[i]
+		var call *transcript.ToolCall
+		switch block.Type {
+		case transcript.BlockToolUse:
+			call = e.tools[block.ID]
+		case transcript.BlockToolResult:
+			call = e.tools[block.ToolUseID]
+		default:
+			continue
+		}
+		if call == nil {
+			continue
+		}
+		if latency, ok := toolLatency(call.Entry, call.Result); ok {
+			longest = max(longest, latency)
+			found = true
+		}
+	}
+	return longest.Seconds(), found
+}
+
+// flag returns the value of a bool field
+func (e *whereEntry) flag(field string) bool {
+	if e.entry.Message == nil {
+		return false
+	}
+	for _, block := range e.entry.Message.Content {
+		if block.Type == transcript.BlockToolResult && block.IsError {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWhere reports whether an entry satisfies the --where expression
+func matchWhere(expr whereExpr, entry *transcript.Entry, tools transcript.ToolIndex) bool {
+	return expr == nil || expr.eval(&whereEntry{entry: entry, tools: tools})
+}
+
+// whereToken is a lexical token of a --where expression
+type whereToken struct {
+	kind string // word, string, regexp, op, (, ) or end
+	text string
+	pos  int // 1-based column
+}
+
+// lexWhere splits an expression into tokens
+func lexWhere(src string) ([]whereToken, error) {
+	var tokens []whereToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, whereToken{string(c), string(c), i + 1})
+			i++
+		case strings.ContainsRune("=!~<>", rune(c)):
+			op := string(c)
+			if i+1 < len(src) && (src[i+1] == '=' || (c == '!' && src[i+1] == '~')) {
+				op = src[i : i+2]
+			}
+			if op == "!" || op == "==" || op == "~=" {
+				return nil, fmt.Errorf("column %d: unknown operator %q", i+1, op)
+			}
+			tokens = append(tokens, whereToken{"op", op, i + 1})
+			i += len(op)
+		case c == '"':
+			end := i + 1
+			for end < len(src) && src[end] != '"' {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(src) {
+				return nil, fmt.Errorf("column %d: unterminated string", i+1)
+			}
+			s, err := strconv.Unquote(src[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("column %d: invalid string: %v", i+1, err)
+			}
+			tokens = append(tokens, whereToken{"string", s, i + 1})
+			i = end + 1
+		case c == '/' && !afterEquals(tokens):
+			var b strings.Builder
+			end := i + 1
+			for ; end < len(src) && src[end] != '/'; end++ {
+				// \/ stands for a slash; other escapes belong to the regexp
+				if src[end] == '\\' && end+1 < len(src) && src[end+1] == '/' {
+					end++
+				} else if src[end] == '\\' && end+1 < len(src) {
+					b.WriteByte(src[end])
+					end++
+				}
+				b.WriteByte(src[end])
+			}
+			if end >= len(src) {
+				return nil, fmt.Errorf("column %d: unterminated regexp (quote a value starting with /)", i+1)
+			}
+			tokens = append(tokens, whereToken{"regexp", b.String(), i + 1})
+			i = end + 1
+		default:
+			// Slashes belong to words, as in cwd=/home/me or gitBranch~feature/*
+			end := i
+			for end < len(src) && !unicode.IsSpace(rune(src[end])) && !strings.ContainsRune("()=!~<>\"", rune(src[end])) {
+				end++
+			}
+			tokens = append(tokens, whereToken{"word", src[i:end], i + 1})
+			i = end
+		}
+	}
+	return append(tokens, whereToken{"end", "", len(src) + 1}), nil
+}
+
+// afterEquals reports whether the next token is the value of = or !=,
+// which is never a regexp
+func afterEquals(tokens []whereToken) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	last := tokens[len(tokens)-1]
+	return last.kind == "op" && (last.text == "=" || last.text == "!=")
+}
+
+// whereParser parses tokens by recursive descent:
+//
+//	or      = and { "or" and }
+//	and     = not { "and" not }
+//	not     = "not" not | "(" or ")" | field [ op value ]
+type whereParser struct {
+	tokens []whereToken
+	pos    int
+}
+
+// compileWhere parses a --where expression
+func compileWhere(src string) (whereExpr, error) {
+	tokens, err := lexWhere(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &whereParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != "end" {
+		return nil, fmt.Errorf("column %d: unexpected %q", tok.pos, tok.text)
+	}
+	return expr, nil
+}
+
+func (p *whereParser) peek() whereToken {
+	return p.tokens[p.pos]
+}
+
+func (p *whereParser) next() whereToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != "end" {
+		p.pos++
+	}
+	return tok
+}
+
+// keyword reports whether the next token is the keyword, consuming it if so
+func (p *whereParser) keyword(word string) bool {
+	if tok := p.peek(); tok.kind == "word" && strings.EqualFold(tok.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *whereParser) parseOr() (whereExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = whereOr{left, right}
+	}
+	return left, nil
+}
+
+func (p *whereParser) parseAnd() (whereExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = whereAnd{left, right}
+	}
+	return left, nil
+}
+
+func (p *whereParser) parseNot() (whereExpr, error) {
+	if p.keyword("not") {
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return whereNot{expr}, nil
+	}
+
+	tok := p.next()
+	switch tok.kind {
+	case "(":
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != ")" {
+			return nil, fmt.Errorf("column %d: expected ) to close column %d", closing.pos, tok.pos)
+		}
+		return expr, nil
+	case "word":
+		return p.parseCompare(tok)
+	case "end":
+		return nil, fmt.Errorf("column %d: unexpected end of expression", tok.pos)
+	}
+	return nil, fmt.Errorf("column %d: unexpected %q", tok.pos, tok.text)
+}
+
+// parseCompare parses the comparison started by a field name
+func (p *whereParser) parseCompare(field whereToken) (whereExpr, error) {
+	kind, ok := whereFields[field.text]
+	if !ok {
+		return nil, fmt.Errorf("column %d: unknown field %q", field.pos, field.text)
+	}
+
+	if p.peek().kind != "op" {
+		// A bool field on its own is true
+		if kind != whereBool {
+			return nil, fmt.Errorf("column %d: expected an operator after %s", p.peek().pos, field.text)
+		}
+		return whereCompare{field: field.text, op: "=", value: "true"}, nil
+	}
+	op := p.next()
+	value := p.next()
+	if value.kind != "word" && value.kind != "string" && value.kind != "regexp" {
+		return nil, fmt.Errorf("column %d: expected a value after %s", value.pos, op.text)
+	}
+
+	cmp := whereCompare{field: field.text, op: op.text, value: value.text}
+	switch kind {
+	case whereString:
+		switch op.text {
+		case "~", "!~":
+			if value.kind == "regexp" {
+				re, err := regexp.Compile(value.text)
+				if err != nil {
+					return nil, fmt.Errorf("column %d: %v", value.pos, err)
+				}
+				cmp.pattern = re
+			}
+		case "=", "!=":
+		default:
+			return nil, fmt.Errorf("column %d: %s is not a number", field.pos, field.text)
+		}
+	case whereBool:
+		if (op.text != "=" && op.text != "!=") || (value.text != "true" && value.text != "false") {
+			return nil, fmt.Errorf("column %d: %s can only be compared with = or != to true or false", op.pos, field.text)
+		}
+	case whereNumber:
+		if op.text == "~" || op.text == "!~" {
+			return nil, fmt.Errorf("column %d: %s is a number and cannot be matched with %s", op.pos, field.text, op.text)
+		}
+		n, err := parseWhereNumber(field.text, value.text)
+		if err != nil {
+			return nil, fmt.Errorf("column %d: %v", value.pos, err)
+		}
+		cmp.number = n
+	}
+	return cmp, nil
+}
+
+// parseWhereNumber parses a number, or a duration in seconds such as 30s or 2m
+func parseWhereNumber(field, s string) (float64, error) {
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return n, nil
+	}
+	if field == "duration" {
+		if d, err := time.ParseDuration(s); err == nil {
+			return d.Seconds(), nil
+		}
+		return 0, fmt.Errorf("%q is not a duration (30s, 2m) or number of seconds", s)
+	}
+	return 0, fmt.Errorf("%q is not a number", s)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Sixeight/ccl/transcript"
+)
+
+func TestWhere(t *testing.T) {
+	input := strings.Join([]string{
+		`{"type":"user","uuid":"u1","timestamp":"2025-06-22T09:00:00Z","cwd":"/work","gitBranch":"main","message":{"role":"user","content":"fix the TODO"}}`,
+		`{"type":"assistant","uuid":"a1","timestamp":"2025-06-22T09:00:05Z","message":{"model":"claude-sonnet-4","content":[{"type":"tool_use","id":"x","name":"Edit","input":{"file_path":"a.go"}}],"usage":{"input_tokens":100,"output_tokens":50}}}`,
+		`{"type":"user","uuid":"t1","timestamp":"2025-06-22T09:00:07Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"x","content":"updated"}]}}`,
+		`{"type":"assistant","uuid":"a2","timestamp":"2025-06-22T09:00:10Z","message":{"model":"claude-opus-4","content":[{"type":"tool_use","id":"y","name":"Bash","input":{"command":"go test"}}],"usage":{"input_tokens":10,"output_tokens":5}}}`,
+		`{"type":"user","uuid":"t2","timestamp":"2025-06-22T09:01:10Z","gitBranch":"feature/x","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"y","content":"FAIL","is_error":true}]}}`,
+	}, "\n")
+	entries, err := transcript.ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	tools := transcript.NewToolIndex(entries)
+
+	tests := map[string]struct {
+		expr     string
+		expected string
+	}{
+		"role":            {"role=tool", "t1 t2"},
+		"not equal":       {"role!=tool", "u1 a1 a2"},
+		"tool glob":       {`tool~"*dit"`, "a1 t1"},
+		"tool negated":    {"role=assistant and tool!~Edit", "a2"},
+		"text regexp":     {"text~/TODO|go test/", "u1 a2"},
+		"example":         {`role=assistant and (tool~"*Edit" or text~/TODO/) and not error`, "a1"},
+		"error":           {"error", "t2"},
+		"error false":     {"error=false and role=tool", "t1"},
+		"tokens":          {"tokens>=150", "a1"},
+		"duration":        {"duration>30s", "a2 t2"},
+		"duration number": {"duration<=2", "a1 t1"},
+		"model":           {`model~"*opus*"`, "a2"},
+		"cwd":             {`cwd="/work"`, "u1"},
+		"unquoted cwd":    {"cwd=/work", "u1"},
+		"branch":          {`gitBranch~"feature/*"`, "t2"},
+		"unquoted branch": {"gitBranch=feature/x or gitBranch~feature/*", "t2"},
+		"precedence":      {"role=user or role=tool and error", "u1 t2"},
+		"keywords case":   {"NOT role=user AND tool=Bash", "a2 t2"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			expr, err := compileWhere(tt.expr)
+			if err != nil {
+				t.Fatalf("compileWhere(%q) error = %v", tt.expr, err)
+			}
+			var matched []string
+			for _, entry := range entries {
+				if matchWhere(expr, entry, tools) {
+					matched = append(matched, entry.UUID)
+				}
+			}
+			if got := strings.Join(matched, " "); got != tt.expected {
+				t.Errorf("%s matched %q; want %q", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCompileWhereErrors(t *testing.T) {
+	tests := map[string]struct {
+		expr     string
+		expected string
+	}{
+		"unknown field":  {"size>1", `column 1: unknown field "size"`},
+		"missing value":  {"role=", "column 6: expected a value after ="},
+		"bare string":    {"role", "column 5: expected an operator after role"},
+		"number match":   {"tokens~1", "column 7: tokens is a number and cannot be matched with ~"},
+		"not a number":   {"tokens>many", `column 8: "many" is not a number`},
+		"string order":   {"model>a", "column 1: model is not a number"},
+		"bad regexp":     {"text~/(/", "column 6: error parsing regexp: missing closing ): `(`"},
+		"unclosed":       {"(role=user", "column 11: expected ) to close column 1"},
+		"trailing":       {"role=user)", `column 10: unexpected ")"`},
+		"dangling and":   {"role=user and", "column 14: unexpected end of expression"},
+		"unknown op":     {"role==user", `column 5: unknown operator "=="`},
+		"open string":    {`tool="Bash`, "column 6: unterminated string"},
+		"unquoted glob":  {"cwd~/work*", "column 5: unterminated regexp (quote a value starting with /)"},
+		"error compared": {"error>1", "column 6: error can only be compared with = or != to true or false"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := compileWhere(tt.expr)
+			if err == nil {
+				t.Fatalf("compileWhere(%q) succeeded; want error %q", tt.expr, tt.expected)
+			}
+			if err.Error() != tt.expected {
+				t.Errorf("compileWhere(%q) error = %q; want %q", tt.expr, err, tt.expected)
+			}
+		})
+	}
+}